@@ -0,0 +1,121 @@
+// Package analytics berisi perhitungan ringkasan emosi yang dipakai bersama oleh controller:
+// emosi dominan, rata-rata, histogram, exponential smoothing, dan pengelompokan waktu.
+// Versi in-memory ada di file ini, versi pipeline MongoDB ada di pipeline.go.
+package analytics
+
+import (
+	"sort"
+	"time"
+
+	"sitor-backend/models"
+)
+
+// Point adalah nilai emosi rata-rata pada satu bucket waktu
+type Point struct {
+	Time   time.Time      `json:"time"`
+	Values models.Emotion `json:"values"`
+	Count  int            `json:"count"`
+}
+
+// TimedEmotion adalah satu sampel emosi beserta waktunya
+type TimedEmotion struct {
+	Time     time.Time
+	Emotions models.Emotion
+}
+
+// Dominant mengembalikan kategori dengan nilai tertinggi. Jika seri, kategori yang lebih awal
+// di daftar menang. Mengembalikan "" jika categories kosong.
+func Dominant(e models.Emotion, categories []string) (string, float64) {
+	label, max := "", -1.0
+	for _, c := range categories {
+		if v := e[c]; v > max {
+			label, max = c, v
+		}
+	}
+	if label == "" {
+		return "", 0
+	}
+	return label, max
+}
+
+// Average menghitung rata-rata tiap label. Label yang tidak dimiliki sebuah sampel
+// tidak ikut dihitung untuk label tersebut; label tanpa nilai sama sekali tidak muncul di hasil.
+func Average(samples []models.Emotion, labels []string) models.Emotion {
+	avg := models.Emotion{}
+	for _, label := range labels {
+		sum, n := 0.0, 0
+		for _, s := range samples {
+			if v, ok := s[label]; ok {
+				sum += v
+				n++
+			}
+		}
+		if n > 0 {
+			avg[label] = sum / float64(n)
+		}
+	}
+	return avg
+}
+
+// Histogram menghitung berapa kali tiap kategori menjadi emosi dominan
+func Histogram(samples []models.Emotion, categories []string) map[string]int {
+	hist := map[string]int{}
+	for _, c := range categories {
+		hist[c] = 0
+	}
+	for _, s := range samples {
+		if dom, _ := Dominant(s, categories); dom != "" {
+			hist[dom]++
+		}
+	}
+	return hist
+}
+
+// BucketStart membulatkan waktu ke awal bucket berukuran size (dihitung dari Unix epoch,
+// sama dengan BucketExpr)
+func BucketStart(t time.Time, size time.Duration) time.Time {
+	ms := size.Milliseconds()
+	if ms <= 0 {
+		return t
+	}
+	unix := t.UnixMilli()
+	return time.UnixMilli(unix - unix%ms).UTC()
+}
+
+// Bucket mengelompokkan sampel ke bucket waktu dan merata-ratakan nilainya, urut dari yang paling awal
+func Bucket(samples []TimedEmotion, size time.Duration, labels []string) []Point {
+	groups := map[time.Time][]models.Emotion{}
+	for _, s := range samples {
+		start := BucketStart(s.Time, size)
+		groups[start] = append(groups[start], s.Emotions)
+	}
+	points := make([]Point, 0, len(groups))
+	for start, emotions := range groups {
+		points = append(points, Point{Time: start, Values: Average(emotions, labels), Count: len(emotions)})
+	}
+	sort.Slice(points, func(i, j int) bool { return points[i].Time.Before(points[j].Time) })
+	return points
+}
+
+// Smooth menerapkan exponential smoothing per label: s[i] = alpha*x[i] + (1-alpha)*s[i-1].
+// alpha di luar (0, 1] dianggap 1 (tanpa smoothing). Points tidak diubah.
+func Smooth(points []Point, alpha float64) []Point {
+	if alpha <= 0 || alpha > 1 {
+		alpha = 1
+	}
+	out := make([]Point, len(points))
+	prev := models.Emotion{}
+	for i, p := range points {
+		values := models.Emotion{}
+		for label, v := range p.Values {
+			if last, ok := prev[label]; ok && i > 0 {
+				values[label] = alpha*v + (1-alpha)*last
+			} else {
+				values[label] = v
+			}
+		}
+		out[i] = Point{Time: p.Time, Values: values, Count: p.Count}
+		prev = values
+	}
+	return out
+}
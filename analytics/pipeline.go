@@ -0,0 +1,139 @@
+package analytics
+
+import (
+	"time"
+
+	"sitor-backend/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// AverageFields membuat ekspresi $avg per label dari path emosi (mis. "emotions" atau
+// "detections.emotions"). Bisa dipakai sebagai accumulator $group maupun di $addFields
+// untuk merata-ratakan isi array. $avg mengabaikan dokumen yang tidak punya label tersebut.
+func AverageFields(path string, labels []string) bson.M {
+	fields := bson.M{}
+	for _, label := range labels {
+		fields[label] = bson.M{"$avg": "$" + path + "." + label}
+	}
+	return fields
+}
+
+// AverageGroup membuat isi stage $group berisi rata-rata tiap label dan jumlah dokumen
+func AverageGroup(id interface{}, path string, labels []string) bson.M {
+	stage := AverageFields(path, labels)
+	stage["_id"] = id
+	stage["count"] = bson.M{"$sum": 1}
+	return stage
+}
+
+// DominantExpr membuat ekspresi yang menghasilkan nama kategori dengan nilai tertinggi
+// dari dokumen emosi di path (mis. "emotions"). Seri dimenangkan kategori yang lebih awal.
+func DominantExpr(path string, categories []string) bson.M {
+	candidates := bson.A{}
+	for _, c := range categories {
+		candidates = append(candidates, bson.M{"k": c, "v": bson.M{"$ifNull": bson.A{"$" + path + "." + c, -1}}})
+	}
+	return bson.M{"$let": bson.M{
+		"vars": bson.M{"best": bson.M{"$reduce": bson.M{
+			"input":        candidates,
+			"initialValue": bson.M{"k": "", "v": -1},
+			"in": bson.M{"$cond": bson.A{
+				bson.M{"$gt": bson.A{"$$this.v", "$$value.v"}}, "$$this", "$$value",
+			}},
+		}}},
+		"in": "$$best.k",
+	}}
+}
+
+// BucketExpr membuat ekspresi awal bucket waktu berukuran size dari field tanggal
+func BucketExpr(timeField string, size time.Duration) bson.M {
+	ms := size.Milliseconds()
+	t := bson.M{"$toLong": "$" + timeField}
+	return bson.M{"$toDate": bson.M{"$subtract": bson.A{t, bson.M{"$mod": bson.A{t, ms}}}}}
+}
+
+// HistogramGroup membuat isi stage $group yang menghitung jumlah dokumen per emosi dominan
+func HistogramGroup(path string, categories []string) bson.M {
+	return bson.M{"_id": DominantExpr(path, categories), "count": bson.M{"$sum": 1}}
+}
+
+// HistogramPipeline menghitung berapa kali tiap kategori menjadi emosi dominan
+func HistogramPipeline(match bson.M, path string, categories []string) mongo.Pipeline {
+	return mongo.Pipeline{
+		{{Key: "$match", Value: match}},
+		{{Key: "$group", Value: HistogramGroup(path, categories)}},
+	}
+}
+
+// TimeSeriesPipeline merata-ratakan emosi per bucket waktu, urut dari yang paling awal
+func TimeSeriesPipeline(match bson.M, timeField, path string, labels []string, size time.Duration) mongo.Pipeline {
+	return mongo.Pipeline{
+		{{Key: "$match", Value: match}},
+		{{Key: "$group", Value: AverageGroup(BucketExpr(timeField, size), path, labels)}},
+		{{Key: "$sort", Value: bson.M{"_id": 1}}},
+	}
+}
+
+// EmotionFromDoc mengambil nilai label dari dokumen hasil agregasi.
+// Label yang null (tidak ada data) dilewati.
+func EmotionFromDoc(doc bson.M, labels []string) models.Emotion {
+	e := models.Emotion{}
+	for _, label := range labels {
+		switch v := doc[label].(type) {
+		case float64:
+			e[label] = v
+		case int32:
+			e[label] = float64(v)
+		case int64:
+			e[label] = float64(v)
+		}
+	}
+	return e
+}
+
+// Count mengambil field count hasil AverageGroup/HistogramGroup
+func Count(doc bson.M) int {
+	return toInt(doc["count"])
+}
+
+// HistogramFromDocs mengubah hasil HistogramPipeline menjadi map kategori ke jumlah
+func HistogramFromDocs(docs []bson.M, categories []string) map[string]int {
+	hist := map[string]int{}
+	for _, c := range categories {
+		hist[c] = 0
+	}
+	for _, d := range docs {
+		label, _ := d["_id"].(string)
+		if _, ok := hist[label]; ok {
+			hist[label] += toInt(d["count"])
+		}
+	}
+	return hist
+}
+
+// PointsFromDocs mengubah hasil TimeSeriesPipeline menjadi Point
+func PointsFromDocs(docs []bson.M, labels []string) []Point {
+	points := make([]Point, 0, len(docs))
+	for _, d := range docs {
+		p := Point{Values: EmotionFromDoc(d, labels), Count: toInt(d["count"])}
+		if t, ok := d["_id"].(interface{ Time() time.Time }); ok {
+			p.Time = t.Time()
+		}
+		points = append(points, p)
+	}
+	return points
+}
+
+func toInt(v interface{}) int {
+	switch n := v.(type) {
+	case int32:
+		return int(n)
+	case int64:
+		return int(n)
+	case float64:
+		return int(n)
+	}
+	return 0
+}
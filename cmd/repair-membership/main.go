@@ -0,0 +1,34 @@
+// Command repair-membership menyamakan User.JoinedGroups dengan Group.Members
+// untuk data lama yang dibuat sebelum keanggotaan dikelola secara transaksional.
+//
+//	go run ./cmd/repair-membership [-dry-run]
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+	"time"
+
+	"sitor-backend/controllers"
+
+	"github.com/joho/godotenv"
+)
+
+func main() {
+	dryRun := flag.Bool("dry-run", false, "only report inconsistencies without fixing them")
+	flag.Parse()
+
+	if err := godotenv.Load(".env"); err != nil {
+		log.Println("No .env file found or failed to load .env")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
+	defer cancel()
+	report, err := controllers.ReconcileMembership(ctx, *dryRun)
+	if err != nil {
+		log.Fatalf("repair failed: %v", err)
+	}
+	log.Printf("groups scanned: %d, users scanned: %d, joinedGroups added: %d, stale entries removed: %d (dry-run: %v)",
+		report.GroupsScanned, report.UsersScanned, report.JoinedGroupsAdded, report.StaleGroupsRemoved, *dryRun)
+}
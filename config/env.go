@@ -0,0 +1,45 @@
+package config
+
+import (
+	"log"
+	"os"
+	"strconv"
+	"time"
+)
+
+// GetEnvDuration membaca durasi dari environment (format time.ParseDuration, mis. "168h"),
+// atau mengembalikan nilai default jika kosong/tidak valid
+func GetEnvDuration(key string, def time.Duration) time.Duration {
+	val := os.Getenv(key)
+	if val == "" {
+		return def
+	}
+	d, err := time.ParseDuration(val)
+	if err != nil {
+		log.Printf("[CONFIG] invalid duration for %s=%q, using default %s", key, val, def)
+		return def
+	}
+	return d
+}
+
+// GetEnvInt membaca angka dari environment, atau mengembalikan nilai default
+func GetEnvInt(key string, def int) int {
+	val := os.Getenv(key)
+	if val == "" {
+		return def
+	}
+	n, err := strconv.Atoi(val)
+	if err != nil {
+		log.Printf("[CONFIG] invalid integer for %s=%q, using default %d", key, val, def)
+		return def
+	}
+	return n
+}
+
+// GetEnv membaca string dari environment, atau mengembalikan nilai default jika kosong
+func GetEnv(key, def string) string {
+	if val := os.Getenv(key); val != "" {
+		return val
+	}
+	return def
+}
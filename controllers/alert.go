@@ -0,0 +1,268 @@
+package controllers
+
+import (
+	"context"
+	"time"
+
+	"sitor-backend/config"
+	"sitor-backend/models"
+	"sitor-backend/taxonomy"
+
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var alertRuleCol = config.GetDB().Collection("alert_rules")
+var alertCol = config.GetDB().Collection("alerts")
+
+// alertRuleBody adalah body create/update aturan alert
+type alertRuleBody struct {
+	Name            string  `json:"name"`
+	Metric          string  `json:"metric"`
+	Label           string  `json:"label"`
+	Threshold       float64 `json:"threshold"`
+	WindowSeconds   int     `json:"windowSeconds"`
+	CooldownSeconds int     `json:"cooldownSeconds"`
+	MinSamples      int     `json:"minSamples"`
+	Enabled         *bool   `json:"enabled"`
+}
+
+// validate memeriksa body aturan dan mengisi nilai default
+func (b *alertRuleBody) validate() string {
+	switch b.Metric {
+	case models.AlertMetricMemberEmotion:
+		if !taxonomy.Current().IsCategory(b.Label) {
+			return "label must be an emotion category for member_emotion"
+		}
+	case models.AlertMetricMemberEngagement, models.AlertMetricClassEngagement, models.AlertMetricClassEngagementDrop:
+		b.Label = ""
+	default:
+		return "Unknown metric"
+	}
+	if b.Name == "" {
+		b.Name = b.Metric
+	}
+	if b.Threshold <= 0 || b.Threshold > 1 {
+		return "threshold must be in (0, 1]"
+	}
+	if b.WindowSeconds == 0 {
+		b.WindowSeconds = 300
+	}
+	if b.WindowSeconds < 30 || b.WindowSeconds > 3600 {
+		return "windowSeconds must be between 30 and 3600"
+	}
+	if b.CooldownSeconds == 0 {
+		b.CooldownSeconds = 600
+	}
+	if b.CooldownSeconds < 0 {
+		return "cooldownSeconds must not be negative"
+	}
+	if b.MinSamples == 0 {
+		b.MinSamples = 5
+	}
+	if b.MinSamples < 1 {
+		return "minSamples must be positive"
+	}
+	return ""
+}
+
+// POST /api/groups/:groupId/alert-rules
+func CreateAlertRule(c *fiber.Ctx) error {
+	var body alertRuleBody
+	if err := c.BodyParser(&body); err != nil {
+		return c.Status(400).JSON(fiber.Map{"success": false, "message": "Invalid request"})
+	}
+	if msg := body.validate(); msg != "" {
+		return c.Status(400).JSON(fiber.Map{"success": false, "message": msg})
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	group, status, msg := loadManagedGroup(ctx, c, "groupId")
+	if status != 0 {
+		return c.Status(status).JSON(fiber.Map{"success": false, "message": msg})
+	}
+	createdBy, _ := primitive.ObjectIDFromHex(c.Locals("userId").(string))
+	rule := models.AlertRule{
+		ID:              primitive.NewObjectID(),
+		GroupID:         group.ID,
+		Name:            body.Name,
+		Metric:          body.Metric,
+		Label:           body.Label,
+		Threshold:       body.Threshold,
+		WindowSeconds:   body.WindowSeconds,
+		CooldownSeconds: body.CooldownSeconds,
+		MinSamples:      body.MinSamples,
+		Enabled:         body.Enabled == nil || *body.Enabled,
+		CreatedBy:       createdBy,
+		CreatedAt:       time.Now(),
+	}
+	if _, err := alertRuleCol.InsertOne(ctx, rule); err != nil {
+		return c.Status(500).JSON(fiber.Map{"success": false, "message": "Failed to create alert rule"})
+	}
+	return c.Status(201).JSON(fiber.Map{"success": true, "rule": rule})
+}
+
+// GET /api/groups/:groupId/alert-rules
+func ListAlertRules(c *fiber.Ctx) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	group, status, msg := loadManagedGroup(ctx, c, "groupId")
+	if status != 0 {
+		return c.Status(status).JSON(fiber.Map{"success": false, "message": msg})
+	}
+	cursor, err := alertRuleCol.Find(ctx, bson.M{"groupId": group.ID}, options.Find().SetSort(bson.M{"createdAt": 1}))
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"success": false, "message": "Failed to fetch alert rules"})
+	}
+	rules := []models.AlertRule{}
+	if err := cursor.All(ctx, &rules); err != nil {
+		return c.Status(500).JSON(fiber.Map{"success": false, "message": "Failed to decode alert rules"})
+	}
+	return c.JSON(fiber.Map{"success": true, "rules": rules})
+}
+
+// PUT /api/groups/:groupId/alert-rules/:ruleId
+func UpdateAlertRule(c *fiber.Ctx) error {
+	ruleId, err := primitive.ObjectIDFromHex(c.Params("ruleId"))
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"success": false, "message": "Invalid ruleId"})
+	}
+	var body alertRuleBody
+	if err := c.BodyParser(&body); err != nil {
+		return c.Status(400).JSON(fiber.Map{"success": false, "message": "Invalid request"})
+	}
+	if msg := body.validate(); msg != "" {
+		return c.Status(400).JSON(fiber.Map{"success": false, "message": msg})
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	group, status, msg := loadManagedGroup(ctx, c, "groupId")
+	if status != 0 {
+		return c.Status(status).JSON(fiber.Map{"success": false, "message": msg})
+	}
+	set := bson.M{
+		"name":            body.Name,
+		"metric":          body.Metric,
+		"label":           body.Label,
+		"threshold":       body.Threshold,
+		"windowSeconds":   body.WindowSeconds,
+		"cooldownSeconds": body.CooldownSeconds,
+		"minSamples":      body.MinSamples,
+	}
+	if body.Enabled != nil {
+		set["enabled"] = *body.Enabled
+	}
+	var rule models.AlertRule
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+	err = alertRuleCol.FindOneAndUpdate(ctx, bson.M{"_id": ruleId, "groupId": group.ID}, bson.M{"$set": set}, opts).Decode(&rule)
+	if err == mongo.ErrNoDocuments {
+		return c.Status(404).JSON(fiber.Map{"success": false, "message": "Alert rule not found"})
+	}
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"success": false, "message": "Failed to update alert rule"})
+	}
+	return c.JSON(fiber.Map{"success": true, "rule": rule})
+}
+
+// DELETE /api/groups/:groupId/alert-rules/:ruleId
+func DeleteAlertRule(c *fiber.Ctx) error {
+	ruleId, err := primitive.ObjectIDFromHex(c.Params("ruleId"))
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"success": false, "message": "Invalid ruleId"})
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	group, status, msg := loadManagedGroup(ctx, c, "groupId")
+	if status != 0 {
+		return c.Status(status).JSON(fiber.Map{"success": false, "message": msg})
+	}
+	res, err := alertRuleCol.DeleteOne(ctx, bson.M{"_id": ruleId, "groupId": group.ID})
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"success": false, "message": "Failed to delete alert rule"})
+	}
+	if res.DeletedCount == 0 {
+		return c.Status(404).JSON(fiber.Map{"success": false, "message": "Alert rule not found"})
+	}
+	return c.JSON(fiber.Map{"success": true})
+}
+
+// GET /api/groups/:groupId/alerts?status=open&limit=50
+func ListAlerts(c *fiber.Ctx) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	group, status, msg := loadManagedGroup(ctx, c, "groupId")
+	if status != 0 {
+		return c.Status(status).JSON(fiber.Map{"success": false, "message": msg})
+	}
+	filter := bson.M{"groupId": group.ID}
+	if s := c.Query("status"); s != "" {
+		filter["status"] = s
+	}
+	limit := c.QueryInt("limit", 50)
+	if limit < 1 || limit > 200 {
+		limit = 50
+	}
+	opts := options.Find().SetSort(bson.M{"triggeredAt": -1}).SetLimit(int64(limit))
+	cursor, err := alertCol.Find(ctx, filter, opts)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"success": false, "message": "Failed to fetch alerts"})
+	}
+	alerts := []models.Alert{}
+	if err := cursor.All(ctx, &alerts); err != nil {
+		return c.Status(500).JSON(fiber.Map{"success": false, "message": "Failed to decode alerts"})
+	}
+	return c.JSON(fiber.Map{"success": true, "alerts": alerts})
+}
+
+// POST /api/groups/:groupId/alerts/:alertId/acknowledge
+func AcknowledgeAlert(c *fiber.Ctx) error {
+	return transitionAlert(c, []string{models.AlertStatusOpen}, models.AlertStatusAcknowledged, "acknowledgedAt", "acknowledgedBy")
+}
+
+// POST /api/groups/:groupId/alerts/:alertId/resolve
+func ResolveAlert(c *fiber.Ctx) error {
+	return transitionAlert(c, []string{models.AlertStatusOpen, models.AlertStatusAcknowledged}, models.AlertStatusResolved, "resolvedAt", "resolvedBy")
+}
+
+// transitionAlert memindahkan status alert jika status sekarang termasuk from
+func transitionAlert(c *fiber.Ctx, from []string, to, atField, byField string) error {
+	alertId, err := primitive.ObjectIDFromHex(c.Params("alertId"))
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"success": false, "message": "Invalid alertId"})
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	group, status, msg := loadManagedGroup(ctx, c, "groupId")
+	if status != 0 {
+		return c.Status(status).JSON(fiber.Map{"success": false, "message": msg})
+	}
+	uid, _ := primitive.ObjectIDFromHex(c.Locals("userId").(string))
+	var alert models.Alert
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+	err = alertCol.FindOneAndUpdate(ctx,
+		bson.M{"_id": alertId, "groupId": group.ID, "status": bson.M{"$in": from}},
+		bson.M{"$set": bson.M{"status": to, atField: time.Now(), byField: uid}}, opts).Decode(&alert)
+	if err == mongo.ErrNoDocuments {
+		count, _ := alertCol.CountDocuments(ctx, bson.M{"_id": alertId, "groupId": group.ID})
+		if count == 0 {
+			return c.Status(404).JSON(fiber.Map{"success": false, "message": "Alert not found"})
+		}
+		return c.Status(409).JSON(fiber.Map{"success": false, "message": "Alert cannot be " + to + " from its current status"})
+	}
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"success": false, "message": "Failed to update alert"})
+	}
+	return c.JSON(fiber.Map{"success": true, "alert": alert})
+}
+
+// ensureAlertIndexes mempercepat daftar alert per grup dan pengecekan cooldown
+func ensureAlertIndexes(ctx context.Context) error {
+	_, err := alertCol.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "groupId", Value: 1}, {Key: "status", Value: 1}, {Key: "triggeredAt", Value: -1}}},
+		{Keys: bson.D{{Key: "ruleId", Value: 1}, {Key: "userId", Value: 1}, {Key: "triggeredAt", Value: -1}}},
+	})
+	return err
+}
@@ -0,0 +1,236 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"sitor-backend/analytics"
+	"sitor-backend/config"
+	"sitor-backend/models"
+	"sitor-backend/taxonomy"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// alertThrottle mencegah aturan dievaluasi di setiap deteksi; evaluasi untuk key yang sama
+// paling sering sekali per ALERT_EVAL_INTERVAL
+var alertThrottle = struct {
+	sync.Mutex
+	last map[string]time.Time
+}{last: map[string]time.Time{}}
+
+func shouldEvaluateAlerts(key string) bool {
+	interval := config.GetEnvDuration("ALERT_EVAL_INTERVAL", 15*time.Second)
+	now := time.Now()
+	alertThrottle.Lock()
+	defer alertThrottle.Unlock()
+	if last, ok := alertThrottle.last[key]; ok && now.Sub(last) < interval {
+		return false
+	}
+	alertThrottle.last[key] = now
+	// Bersihkan key lama agar map tidak terus membesar
+	for k, t := range alertThrottle.last {
+		if now.Sub(t) > time.Hour {
+			delete(alertThrottle.last, k)
+		}
+	}
+	return true
+}
+
+// evaluateAlertsAsync mengevaluasi aturan alert grup setelah deteksi masuk, di goroutine terpisah
+// agar tidak memperlambat ingest. Aturan per anggota dinilai untuk pengirim deteksi,
+// aturan tingkat kelas dinilai untuk seluruh sesi.
+func evaluateAlertsAsync(target detectionTarget) {
+	if target.Session.ID.IsZero() {
+		return
+	}
+	member := shouldEvaluateAlerts(target.GroupID.Hex() + ":" + target.UserID.Hex())
+	class := shouldEvaluateAlerts(target.GroupID.Hex())
+	if !member && !class {
+		return
+	}
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+		defer cancel()
+		if err := evaluateAlertRules(ctx, target.Session, target.UserID, member, class); err != nil {
+			log.Printf("[ALERT] failed to evaluate rules for group %s: %v", target.GroupID.Hex(), err)
+		}
+	}()
+}
+
+func evaluateAlertRules(ctx context.Context, session models.Session, userId primitive.ObjectID, member, class bool) error {
+	cursor, err := alertRuleCol.Find(ctx, bson.M{"groupId": session.GroupID, "enabled": true})
+	if err != nil {
+		return err
+	}
+	var rules []models.AlertRule
+	if err := cursor.All(ctx, &rules); err != nil {
+		return err
+	}
+	for _, rule := range rules {
+		isMemberRule := rule.Metric == models.AlertMetricMemberEmotion || rule.Metric == models.AlertMetricMemberEngagement
+		if (isMemberRule && !member) || (!isMemberRule && !class) {
+			continue
+		}
+		subject := primitive.NilObjectID
+		if isMemberRule {
+			subject = userId
+		}
+		value, triggered, err := evaluateAlertRule(ctx, rule, session, subject)
+		if err != nil {
+			log.Printf("[ALERT] failed to evaluate rule %s: %v", rule.ID.Hex(), err)
+			continue
+		}
+		if triggered {
+			triggerAlert(ctx, rule, session, subject, value)
+		}
+	}
+	return nil
+}
+
+// alertSampleStats menghitung dari sampel di jendela: jumlah sampel, porsi sampel dengan emosi
+// dominan label, serta rata-rata dan jumlah sampel engagement
+type alertSampleStats struct {
+	Count             int      `bson:"count"`
+	Hits              int      `bson:"hits"`
+	Engagement        *float64 `bson:"engagement"`
+	EngagementSamples int      `bson:"engagementSamples"`
+}
+
+func sampleStatsForAlert(ctx context.Context, match bson.M, label string) (alertSampleStats, error) {
+	var stats alertSampleStats
+	categories := taxonomy.Current().Categories
+	cursor, err := detectionSampleCol.Aggregate(ctx, mongo.Pipeline{
+		{{Key: "$match", Value: match}},
+		{{Key: "$group", Value: bson.M{
+			"_id":   nil,
+			"count": bson.M{"$sum": 1},
+			"hits": bson.M{"$sum": bson.M{"$cond": bson.A{
+				bson.M{"$eq": bson.A{analytics.DominantExpr("emotions", categories), label}}, 1, 0,
+			}}},
+			"engagement": bson.M{"$avg": "$engagement"},
+			"engagementSamples": bson.M{"$sum": bson.M{"$cond": bson.A{
+				bson.M{"$eq": bson.A{bson.M{"$type": "$engagement"}, "missing"}}, 0, 1,
+			}}},
+		}}},
+	})
+	if err != nil {
+		return stats, err
+	}
+	var docs []alertSampleStats
+	if err := cursor.All(ctx, &docs); err != nil {
+		return stats, err
+	}
+	if len(docs) > 0 {
+		stats = docs[0]
+	}
+	return stats, nil
+}
+
+// evaluateAlertRule mengembalikan nilai metrik dan apakah aturan terpicu
+func evaluateAlertRule(ctx context.Context, rule models.AlertRule, session models.Session, subject primitive.ObjectID) (float64, bool, error) {
+	window := time.Duration(rule.WindowSeconds) * time.Second
+	match := bson.M{"meta.sessionId": session.ID, "timestamp": bson.M{"$gte": time.Now().Add(-window)}}
+	if !subject.IsZero() {
+		match["meta.userId"] = subject
+	}
+	switch rule.Metric {
+	case models.AlertMetricMemberEmotion:
+		stats, err := sampleStatsForAlert(ctx, match, rule.Label)
+		if err != nil || stats.Count < rule.MinSamples {
+			return 0, false, err
+		}
+		share := float64(stats.Hits) / float64(stats.Count)
+		return share, share >= rule.Threshold, nil
+	case models.AlertMetricMemberEngagement, models.AlertMetricClassEngagement:
+		stats, err := sampleStatsForAlert(ctx, match, "")
+		if err != nil || stats.Engagement == nil || stats.EngagementSamples < rule.MinSamples {
+			return 0, false, err
+		}
+		return *stats.Engagement, *stats.Engagement < rule.Threshold, nil
+	case models.AlertMetricClassEngagementDrop:
+		mood, err := computeGroupMood(ctx, session, window)
+		if err != nil || mood.EngagementDelta == nil {
+			return 0, false, err
+		}
+		if mood.Current.Samples < rule.MinSamples || mood.Previous.Samples < rule.MinSamples {
+			return 0, false, nil
+		}
+		drop := -*mood.EngagementDelta
+		return drop, drop >= rule.Threshold, nil
+	}
+	return 0, false, fmt.Errorf("unknown metric %s", rule.Metric)
+}
+
+// triggerAlert menyimpan alert dan memberi tahu leader, kecuali aturan yang sama
+// untuk target yang sama sudah memicu alert dalam masa cooldown
+func triggerAlert(ctx context.Context, rule models.AlertRule, session models.Session, subject primitive.ObjectID, value float64) {
+	now := time.Now()
+	cooldown := bson.M{"ruleId": rule.ID, "triggeredAt": bson.M{"$gte": now.Add(-time.Duration(rule.CooldownSeconds) * time.Second)}}
+	if subject.IsZero() {
+		cooldown["userId"] = bson.M{"$exists": false}
+	} else {
+		cooldown["userId"] = subject
+	}
+	if count, err := alertCol.CountDocuments(ctx, cooldown); err != nil || count > 0 {
+		return
+	}
+	group, err := findGroup(ctx, session.GroupID)
+	if err != nil {
+		return
+	}
+	alert := models.Alert{
+		ID:          primitive.NewObjectID(),
+		GroupID:     session.GroupID,
+		SessionID:   session.ID,
+		RuleID:      rule.ID,
+		Metric:      rule.Metric,
+		UserID:      subject,
+		Value:       value,
+		Threshold:   rule.Threshold,
+		Message:     alertMessage(ctx, rule, subject, value),
+		Status:      models.AlertStatusOpen,
+		TriggeredAt: now,
+	}
+	if _, err := alertCol.InsertOne(ctx, alert); err != nil {
+		log.Printf("[ALERT] failed to save alert for rule %s: %v", rule.ID.Hex(), err)
+		return
+	}
+	log.Printf("[ALERT] rule %s triggered in group %s: %s", rule.ID.Hex(), group.ID.Hex(), alert.Message)
+	notifyUser(ctx, models.Notification{
+		UserID:    group.LeaderID,
+		GroupID:   group.ID,
+		SessionID: session.ID,
+		Type:      "alert.triggered",
+		Message:   fmt.Sprintf("[%s] %s", group.Name, alert.Message),
+	})
+	emitWebhookEvent(ctx, group.ID, models.WebhookEventAlertTriggered, alert)
+}
+
+// alertMessage membuat pesan alert yang mudah dibaca leader
+func alertMessage(ctx context.Context, rule models.AlertRule, subject primitive.ObjectID, value float64) string {
+	minutes := rule.WindowSeconds / 60
+	name := ""
+	if !subject.IsZero() {
+		var user models.User
+		if err := userCol.FindOne(ctx, bson.M{"_id": subject}).Decode(&user); err == nil {
+			name = user.Name
+		}
+	}
+	switch rule.Metric {
+	case models.AlertMetricMemberEmotion:
+		return fmt.Sprintf("%s didominasi emosi %s selama %d menit terakhir (%.0f%% sampel).", name, rule.Label, minutes, value*100)
+	case models.AlertMetricMemberEngagement:
+		return fmt.Sprintf("Engagement %s rendah (%.0f%%) selama %d menit terakhir.", name, value*100, minutes)
+	case models.AlertMetricClassEngagement:
+		return fmt.Sprintf("Engagement kelas rendah (%.0f%%) selama %d menit terakhir.", value*100, minutes)
+	case models.AlertMetricClassEngagementDrop:
+		return fmt.Sprintf("Engagement kelas turun %.0f poin dalam %d menit terakhir.", value*100, minutes)
+	}
+	return rule.Name
+}
@@ -0,0 +1,380 @@
+package controllers
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"fmt"
+	"log"
+	"sort"
+	"strconv"
+	"time"
+
+	"sitor-backend/config"
+	"sitor-backend/models"
+
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var attendanceCol = config.GetDB().Collection("attendance_events")
+
+// recordAttendance menyimpan satu event kehadiran, error hanya dicatat di log
+func recordAttendance(ctx context.Context, sessionId, groupId, userId primitive.ObjectID, eventType string) {
+	if sessionId.IsZero() {
+		return
+	}
+	_, err := attendanceCol.InsertOne(ctx, models.AttendanceEvent{
+		ID:        primitive.NewObjectID(),
+		SessionID: sessionId,
+		GroupID:   groupId,
+		UserID:    userId,
+		Type:      eventType,
+		At:        time.Now(),
+	})
+	if err != nil {
+		log.Printf("[ATTENDANCE] failed to record %s for user %s: %v", eventType, userId.Hex(), err)
+	}
+}
+
+// ensureJoined mencatat event join jika user belum tercatat berada di sesi
+func ensureJoined(ctx context.Context, sessionId, groupId, userId primitive.ObjectID) {
+	if sessionId.IsZero() {
+		return
+	}
+	var last models.AttendanceEvent
+	opts := options.FindOne().SetSort(bson.M{"at": -1})
+	err := attendanceCol.FindOne(ctx, bson.M{
+		"sessionId": sessionId,
+		"userId":    userId,
+		"type":      bson.M{"$in": []string{models.AttendanceJoin, models.AttendanceLeave}},
+	}, opts).Decode(&last)
+	if err == nil && last.Type == models.AttendanceJoin {
+		return
+	}
+	recordAttendance(ctx, sessionId, groupId, userId, models.AttendanceJoin)
+}
+
+type attendanceRecord struct {
+	UserID          primitive.ObjectID `json:"userId"`
+	Name            string             `json:"name"`
+	Email           string             `json:"email"`
+	Present         bool               `json:"present"`
+	Late            bool               `json:"late"`
+	LeftEarly       bool               `json:"leftEarly"`
+	JoinedAt        *time.Time         `json:"joinedAt"`
+	LeftAt          *time.Time         `json:"leftAt"`
+	OnCameraSeconds float64            `json:"onCameraSeconds"`
+}
+
+// summarizeAttendance menghitung kehadiran per user dari event sesi yang sudah terurut waktu.
+// Interval yang masih terbuka ditutup pada waktu until (akhir sesi atau sekarang).
+func summarizeAttendance(session models.Session, events []models.AttendanceEvent, until time.Time) map[primitive.ObjectID]*attendanceRecord {
+	lateAfter := config.GetEnvDuration("ATTENDANCE_LATE_AFTER", 5*time.Minute)
+	earlyLeave := config.GetEnvDuration("ATTENDANCE_EARLY_LEAVE", 5*time.Minute)
+	type state struct {
+		inSession   bool
+		cameraOn    bool
+		cameraSince time.Time
+	}
+	states := map[primitive.ObjectID]*state{}
+	records := map[primitive.ObjectID]*attendanceRecord{}
+	for _, e := range events {
+		st, ok := states[e.UserID]
+		if !ok {
+			st = &state{}
+			states[e.UserID] = st
+			records[e.UserID] = &attendanceRecord{UserID: e.UserID}
+		}
+		rec := records[e.UserID]
+		at := e.At
+		switch e.Type {
+		case models.AttendanceJoin:
+			if !st.inSession {
+				st.inSession = true
+				rec.Present = true
+				if rec.JoinedAt == nil {
+					rec.JoinedAt = &at
+				}
+				rec.LeftAt = nil
+			}
+		case models.AttendanceLeave:
+			st.inSession = false
+			rec.LeftAt = &at
+			if st.cameraOn {
+				rec.OnCameraSeconds += at.Sub(st.cameraSince).Seconds()
+				st.cameraOn = false
+			}
+		case models.AttendanceCameraOn:
+			if !st.cameraOn {
+				st.cameraOn = true
+				st.cameraSince = at
+			}
+		case models.AttendanceCameraOff:
+			if st.cameraOn {
+				rec.OnCameraSeconds += at.Sub(st.cameraSince).Seconds()
+				st.cameraOn = false
+			}
+		}
+	}
+	for uid, st := range states {
+		rec := records[uid]
+		if st.cameraOn && until.After(st.cameraSince) {
+			rec.OnCameraSeconds += until.Sub(st.cameraSince).Seconds()
+		}
+		if rec.JoinedAt != nil && rec.JoinedAt.After(session.StartedAt.Add(lateAfter)) {
+			rec.Late = true
+		}
+		if session.EndedAt != nil && !st.inSession && rec.LeftAt != nil && rec.LeftAt.Before(session.EndedAt.Add(-earlyLeave)) {
+			rec.LeftEarly = true
+		}
+	}
+	return records
+}
+
+// buildSessionAttendance membuat laporan kehadiran lengkap (termasuk anggota yang tidak hadir)
+func buildSessionAttendance(ctx context.Context, session models.Session, group models.Group) ([]attendanceRecord, error) {
+	opts := options.Find().SetSort(bson.M{"at": 1})
+	cursor, err := attendanceCol.Find(ctx, bson.M{"sessionId": session.ID}, opts)
+	if err != nil {
+		return nil, err
+	}
+	var events []models.AttendanceEvent
+	if err := cursor.All(ctx, &events); err != nil {
+		return nil, err
+	}
+	until := time.Now()
+	if session.EndedAt != nil {
+		until = *session.EndedAt
+	}
+	records := summarizeAttendance(session, events, until)
+	userIds := []primitive.ObjectID{}
+	for _, uid := range append(append([]primitive.ObjectID{}, group.Members...), session.Participants...) {
+		if _, ok := records[uid]; !ok {
+			records[uid] = &attendanceRecord{UserID: uid}
+		}
+	}
+	for uid := range records {
+		userIds = append(userIds, uid)
+	}
+	cursor, err = userCol.Find(ctx, bson.M{"_id": bson.M{"$in": userIds}})
+	if err != nil {
+		return nil, err
+	}
+	var users []models.User
+	if err := cursor.All(ctx, &users); err != nil {
+		return nil, err
+	}
+	for _, u := range users {
+		records[u.ID].Name = u.Name
+		records[u.ID].Email = u.Email
+	}
+	result := []attendanceRecord{}
+	for _, rec := range records {
+		result = append(result, *rec)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Name < result[j].Name })
+	return result, nil
+}
+
+func formatOptionalTime(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return t.Format(time.RFC3339)
+}
+
+// sendCSV mengirim rows sebagai file CSV
+func sendCSV(c *fiber.Ctx, filename string, rows [][]string) error {
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	if err := w.WriteAll(rows); err != nil {
+		return c.Status(500).JSON(fiber.Map{"success": false, "message": "Failed to write CSV"})
+	}
+	c.Set(fiber.HeaderContentType, "text/csv; charset=utf-8")
+	c.Set(fiber.HeaderContentDisposition, `attachment; filename="`+filename+`"`)
+	return c.Send(buf.Bytes())
+}
+
+// POST /api/groups/:groupId/session/join
+func JoinSession(c *fiber.Ctx) error {
+	return recordSessionPresence(c, models.AttendanceJoin)
+}
+
+// POST /api/groups/:groupId/session/leave
+func LeaveSession(c *fiber.Ctx) error {
+	return recordSessionPresence(c, models.AttendanceLeave)
+}
+
+func recordSessionPresence(c *fiber.Ctx, eventType string) error {
+	userId := c.Locals("userId")
+	if userId == nil {
+		return c.Status(401).JSON(fiber.Map{"success": false, "message": "Unauthorized"})
+	}
+	uid, err := primitive.ObjectIDFromHex(userId.(string))
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"success": false, "message": "Invalid userId"})
+	}
+	gid, err := primitive.ObjectIDFromHex(c.Params("groupId"))
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"success": false, "message": "Invalid groupId"})
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	group, err := findGroup(ctx, gid)
+	if err != nil {
+		return c.Status(404).JSON(fiber.Map{"success": false, "message": "Group not found"})
+	}
+	if !groupHasMember(group, uid) {
+		return c.Status(403).JSON(fiber.Map{"success": false, "message": "Not a member of this group"})
+	}
+	session, ok := findActiveSession(ctx, gid)
+	if !ok {
+		return c.Status(409).JSON(fiber.Map{"success": false, "message": "Session is not active"})
+	}
+	if eventType == models.AttendanceJoin {
+		ensureJoined(ctx, session.ID, gid, uid)
+		trackSessionParticipant(ctx, session.ID, uid, false)
+		return c.JSON(fiber.Map{"success": true, "sessionId": session.ID})
+	}
+	// Keluar dari sesi juga mematikan kamera
+	var cam models.CameraStatus
+	err = cameraStatusCol.FindOneAndUpdate(ctx, bson.M{"groupId": gid, "userId": uid, "isActive": true},
+		bson.M{"$set": bson.M{"isActive": false, "updatedAt": time.Now()}}).Decode(&cam)
+	if err == nil {
+		recordAttendance(ctx, session.ID, gid, uid, models.AttendanceCameraOff)
+	}
+	recordAttendance(ctx, session.ID, gid, uid, models.AttendanceLeave)
+	return c.JSON(fiber.Map{"success": true, "sessionId": session.ID})
+}
+
+// GET /api/sessions/:id/attendance?format=csv
+func GetSessionAttendance(c *fiber.Ctx) error {
+	userId := c.Locals("userId")
+	if userId == nil {
+		return c.Status(401).JSON(fiber.Map{"success": false, "message": "Unauthorized"})
+	}
+	sid, err := primitive.ObjectIDFromHex(c.Params("id"))
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"success": false, "message": "Invalid sessionId"})
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+	var session models.Session
+	if err := sessionCol.FindOne(ctx, bson.M{"_id": sid}).Decode(&session); err != nil {
+		return c.Status(404).JSON(fiber.Map{"success": false, "message": "Session not found"})
+	}
+	group, err := findGroup(ctx, session.GroupID)
+	if err != nil {
+		return c.Status(404).JSON(fiber.Map{"success": false, "message": "Group not found"})
+	}
+	if !canManageGroup(ctx, group, userId.(string)) {
+		return c.Status(403).JSON(fiber.Map{"success": false, "message": "Only the group leader or an organization admin can view attendance"})
+	}
+	records, err := buildSessionAttendance(ctx, session, group)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"success": false, "message": "Failed to build attendance report"})
+	}
+	if c.Query("format") == "csv" {
+		rows := [][]string{{"name", "email", "present", "late", "leftEarly", "joinedAt", "leftAt", "onCameraSeconds"}}
+		for _, r := range records {
+			rows = append(rows, []string{
+				r.Name, r.Email,
+				strconv.FormatBool(r.Present), strconv.FormatBool(r.Late), strconv.FormatBool(r.LeftEarly),
+				formatOptionalTime(r.JoinedAt), formatOptionalTime(r.LeftAt),
+				strconv.FormatFloat(r.OnCameraSeconds, 'f', 0, 64),
+			})
+		}
+		return sendCSV(c, "attendance-"+session.ID.Hex()+".csv", rows)
+	}
+	return c.JSON(fiber.Map{"success": true, "session": session, "attendance": records})
+}
+
+// GET /api/groups/:groupId/attendance?from=YYYY-MM-DD&to=YYYY-MM-DD&format=csv
+// Rekap kehadiran per anggota untuk semua sesi yang dimulai dalam rentang tanggal
+func GetGroupAttendance(c *fiber.Ctx) error {
+	from, err := time.ParseInLocation("2006-01-02", c.Query("from"), time.Local)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"success": false, "message": "from must use YYYY-MM-DD format"})
+	}
+	to, err := time.ParseInLocation("2006-01-02", c.Query("to"), time.Local)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"success": false, "message": "to must use YYYY-MM-DD format"})
+	}
+	if to.Before(from) || to.Sub(from) > 366*24*time.Hour {
+		return c.Status(400).JSON(fiber.Map{"success": false, "message": "Invalid date range (maximum one year)"})
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	group, status, msg := loadManagedGroup(ctx, c, "groupId")
+	if status != 0 {
+		return c.Status(status).JSON(fiber.Map{"success": false, "message": msg})
+	}
+	cursor, err := sessionCol.Find(ctx, bson.M{
+		"groupId":   group.ID,
+		"startedAt": bson.M{"$gte": from, "$lt": to.AddDate(0, 0, 1)},
+	}, options.Find().SetSort(bson.M{"startedAt": 1}))
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"success": false, "message": "Failed to fetch sessions"})
+	}
+	var sessions []models.Session
+	if err := cursor.All(ctx, &sessions); err != nil {
+		return c.Status(500).JSON(fiber.Map{"success": false, "message": "Failed to decode sessions"})
+	}
+
+	type memberTotals struct {
+		UserID          primitive.ObjectID `json:"userId"`
+		Name            string             `json:"name"`
+		Email           string             `json:"email"`
+		Sessions        int                `json:"sessions"`
+		Present         int                `json:"present"`
+		Late            int                `json:"late"`
+		LeftEarly       int                `json:"leftEarly"`
+		OnCameraSeconds float64            `json:"onCameraSeconds"`
+	}
+	totals := map[primitive.ObjectID]*memberTotals{}
+	for _, s := range sessions {
+		records, err := buildSessionAttendance(ctx, s, group)
+		if err != nil {
+			return c.Status(500).JSON(fiber.Map{"success": false, "message": "Failed to build attendance report"})
+		}
+		for _, r := range records {
+			t, ok := totals[r.UserID]
+			if !ok {
+				t = &memberTotals{UserID: r.UserID, Name: r.Name, Email: r.Email}
+				totals[r.UserID] = t
+			}
+			t.Sessions++
+			if r.Present {
+				t.Present++
+			}
+			if r.Late {
+				t.Late++
+			}
+			if r.LeftEarly {
+				t.LeftEarly++
+			}
+			t.OnCameraSeconds += r.OnCameraSeconds
+		}
+	}
+	report := []memberTotals{}
+	for _, t := range totals {
+		report = append(report, *t)
+	}
+	sort.Slice(report, func(i, j int) bool { return report[i].Name < report[j].Name })
+
+	if c.Query("format") == "csv" {
+		rows := [][]string{{"name", "email", "sessions", "present", "late", "leftEarly", "onCameraSeconds"}}
+		for _, t := range report {
+			rows = append(rows, []string{
+				t.Name, t.Email,
+				strconv.Itoa(t.Sessions), strconv.Itoa(t.Present), strconv.Itoa(t.Late), strconv.Itoa(t.LeftEarly),
+				strconv.FormatFloat(t.OnCameraSeconds, 'f', 0, 64),
+			})
+		}
+		return sendCSV(c, fmt.Sprintf("attendance-%s-%s-%s.csv", group.ID.Hex(), c.Query("from"), c.Query("to")), rows)
+	}
+	return c.JSON(fiber.Map{"success": true, "sessions": len(sessions), "attendance": report})
+}
@@ -5,8 +5,10 @@ import (
 	"strings"
 	"time"
 
+	"sitor-backend/analytics"
 	"sitor-backend/config"
 	"sitor-backend/models"
+	"sitor-backend/taxonomy"
 	"sitor-backend/utils"
 
 	"github.com/gofiber/fiber/v2"
@@ -50,6 +52,7 @@ func Register(c *fiber.Ctx) error {
 	if err != nil {
 		return c.Status(500).JSON(fiber.Map{"success": false, "message": "Failed to register"})
 	}
+	// Undangan grup (dari import roster) tidak diterima otomatis; user menerimanya lewat POST /api/invites/accept
 	token, _ := utils.GenerateJWT(user.ID.Hex(), user.Email, "netral")
 	return c.JSON(fiber.Map{
 		"success": true,
@@ -211,18 +214,31 @@ func MeSummary(c *fiber.Ctx) error {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
-	// Ambil semua deteksi user
-	cursor, err := detectionCol.Find(ctx, bson.M{"userId": objId})
+	// Ringkasan dihitung di MongoDB, tidak perlu memuat semua deteksi ke memori
+	tax := taxonomy.Current()
+	labels := tax.Labels()
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"userId": objId}}},
+		{{Key: "$facet", Value: bson.M{
+			"stats":     bson.A{bson.M{"$group": analytics.AverageGroup(nil, "emotions", labels)}},
+			"histogram": bson.A{bson.M{"$group": analytics.HistogramGroup("emotions", tax.Categories)}},
+			"recent":    bson.A{bson.M{"$sort": bson.M{"timestamp": -1}}, bson.M{"$limit": 5}},
+		}}},
+	}
+	cursor, err := detectionCol.Aggregate(ctx, pipeline)
 	if err != nil {
 		return c.Status(500).JSON(fiber.Map{"success": false, "message": "Failed to fetch detections"})
 	}
-	var detections []models.Detection
-	if err := cursor.All(ctx, &detections); err != nil {
+	var facets []struct {
+		Stats     []bson.M           `bson:"stats"`
+		Histogram []bson.M           `bson:"histogram"`
+		Recent    []models.Detection `bson:"recent"`
+	}
+	if err := cursor.All(ctx, &facets); err != nil {
 		return c.Status(500).JSON(fiber.Map{"success": false, "message": "Failed to decode detections"})
 	}
 
-	total := len(detections)
-	if total == 0 {
+	if len(facets) == 0 || len(facets[0].Stats) == 0 {
 		return c.JSON(fiber.Map{
 			"success": true,
 			"summary": fiber.Map{
@@ -233,99 +249,32 @@ func MeSummary(c *fiber.Ctx) error {
 			},
 		})
 	}
+	stats := facets[0].Stats[0]
+	avg := analytics.EmotionFromDoc(stats, labels)
+	// Dominan rata-rata (hanya kategori, dimensi seperti valence tidak ikut)
+	dominant, _ := analytics.Dominant(avg, tax.Categories)
 
-	// Hitung rata-rata emosi
-	sum := map[string]float64{
-		"neutral": 0, "happy": 0, "sad": 0, "angry": 0, "surprised": 0, "disgusted": 0,
-	}
-	for _, d := range detections {
-		sum["neutral"] += d.Emotions.Neutral
-		sum["happy"] += d.Emotions.Happy
-		sum["sad"] += d.Emotions.Sad
-		sum["angry"] += d.Emotions.Angry
-		sum["surprised"] += d.Emotions.Surprised
-		sum["disgusted"] += d.Emotions.Disgusted
-	}
-	avg := map[string]float64{}
-	for k, v := range sum {
-		avg[k] = v / float64(total)
-	}
-
-	// Deteksi terakhir
-	last := detections[total-1]
-
-	// 5 deteksi terakhir (reverse order)
+	// 5 deteksi terakhir (terbaru lebih dulu)
 	recent := []fiber.Map{}
-	for i := total - 1; i >= 0 && len(recent) < 5; i-- {
-		d := detections[i]
-		dom := "neutral"
-		max := d.Emotions.Neutral
-		if d.Emotions.Happy > max {
-			dom, max = "happy", d.Emotions.Happy
-		}
-		if d.Emotions.Sad > max {
-			dom, max = "sad", d.Emotions.Sad
-		}
-		if d.Emotions.Angry > max {
-			dom, max = "angry", d.Emotions.Angry
-		}
-		if d.Emotions.Surprised > max {
-			dom, max = "surprised", d.Emotions.Surprised
-		}
-		if d.Emotions.Disgusted > max {
-			dom, max = "disgusted", d.Emotions.Disgusted
-		}
+	for _, d := range facets[0].Recent {
+		dom, prob := analytics.Dominant(d.Emotions, tax.Categories)
 		recent = append(recent, fiber.Map{
 			"timestamp":   d.Timestamp,
 			"dominant":    dom,
-			"probability": max,
+			"probability": prob,
 		})
 	}
-
-	// Hitung histogram dominan emosi
-	histogram := map[string]int{
-		"neutral": 0, "happy": 0, "sad": 0, "angry": 0, "surprised": 0, "disgusted": 0,
-	}
-	for _, d := range detections {
-		dom := "neutral"
-		max := d.Emotions.Neutral
-		if d.Emotions.Happy > max {
-			dom, max = "happy", d.Emotions.Happy
-		}
-		if d.Emotions.Sad > max {
-			dom, max = "sad", d.Emotions.Sad
-		}
-		if d.Emotions.Angry > max {
-			dom, max = "angry", d.Emotions.Angry
-		}
-		if d.Emotions.Surprised > max {
-			dom, max = "surprised", d.Emotions.Surprised
-		}
-		if d.Emotions.Disgusted > max {
-			dom, max = "disgusted", d.Emotions.Disgusted
-		}
-		histogram[dom]++
-	}
-
-	// Dominan rata-rata
-	dominant := "neutral"
-	maxAvg := avg["neutral"]
-	for k, v := range avg {
-		if v > maxAvg {
-			dominant = k
-			maxAvg = v
-		}
-	}
+	last := facets[0].Recent[0]
 
 	return c.JSON(fiber.Map{
 		"success": true,
 		"summary": fiber.Map{
-			"total": total,
+			"total": analytics.Count(stats),
 			"averageEmotion": fiber.Map{
 				"distribution": avg,
 				"dominant":     dominant,
 			},
-			"histogram": histogram,
+			"histogram": analytics.HistogramFromDocs(facets[0].Histogram, tax.Categories),
 			"lastDetection": fiber.Map{
 				"timestamp": last.Timestamp,
 				"emotions":  last.Emotions,
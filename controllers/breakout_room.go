@@ -0,0 +1,400 @@
+package controllers
+
+import (
+	"context"
+	"time"
+
+	"sitor-backend/analytics"
+	"sitor-backend/config"
+	"sitor-backend/models"
+	"sitor-backend/taxonomy"
+
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+var breakoutRoomCol = config.GetDB().Collection("breakout_rooms")
+
+// groupHasMember mengecek apakah user termasuk anggota grup
+func groupHasMember(group models.Group, userId primitive.ObjectID) bool {
+	for _, m := range group.Members {
+		if m == userId {
+			return true
+		}
+	}
+	return false
+}
+
+// canViewGroup: anggota grup, leader, atau admin organisasi pemilik grup boleh melihat data grup
+func canViewGroup(ctx context.Context, group models.Group, userId string) bool {
+	uid, err := primitive.ObjectIDFromHex(userId)
+	if err != nil {
+		return false
+	}
+	return groupHasMember(group, uid) || canManageGroup(ctx, group, userId)
+}
+
+// parseMemberIds mengubah daftar hex ID menjadi ObjectID dan memastikan semuanya anggota grup
+func parseMemberIds(group models.Group, ids []string) ([]primitive.ObjectID, string) {
+	members := []primitive.ObjectID{}
+	for _, id := range ids {
+		oid, err := primitive.ObjectIDFromHex(id)
+		if err != nil {
+			return nil, "Invalid member id: " + id
+		}
+		if !groupHasMember(group, oid) {
+			return nil, "User is not a member of this group: " + id
+		}
+		members = append(members, oid)
+	}
+	return members, ""
+}
+
+// roomEmotionStats merata-ratakan sampel deteksi anggota room selama room dibuka
+// (sejak dibuat sampai ditutup, atau sampai sekarang jika masih dibuka) beserta jumlah sampelnya
+func roomEmotionStats(ctx context.Context, room models.BreakoutRoom) (models.Emotion, int, error) {
+	average := models.Emotion{}
+	if len(room.Members) == 0 {
+		return average, 0, nil
+	}
+	until := time.Now()
+	if room.ClosedAt != nil {
+		until = *room.ClosedAt
+	}
+	match := bson.M{
+		"meta.groupId": room.GroupID,
+		"meta.userId":  bson.M{"$in": room.Members},
+		"timestamp":    bson.M{"$gte": room.CreatedAt, "$lte": until},
+	}
+	// Room lama tanpa sessionId memakai sampel tanpa sessionId
+	if room.SessionID.IsZero() {
+		match["meta.sessionId"] = bson.M{"$exists": false}
+	} else {
+		match["meta.sessionId"] = room.SessionID
+	}
+	labels := taxonomy.Current().Labels()
+	cursor, err := detectionSampleCol.Aggregate(ctx, mongo.Pipeline{
+		{{Key: "$match", Value: match}},
+		{{Key: "$group", Value: analytics.AverageGroup(nil, "emotions", labels)}},
+	})
+	if err != nil {
+		return average, 0, err
+	}
+	var docs []bson.M
+	if err := cursor.All(ctx, &docs); err != nil {
+		return average, 0, err
+	}
+	if len(docs) == 0 {
+		return average, 0, nil
+	}
+	return analytics.EmotionFromDoc(docs[0], labels), analytics.Count(docs[0]), nil
+}
+
+// loadRoom mengambil grup dan breakout room dari parameter :groupId dan :roomId,
+// dan memastikan user boleh melihat grup tersebut.
+// Jika gagal, status dan pesan error dikembalikan untuk respons handler.
+func loadRoom(ctx context.Context, c *fiber.Ctx) (models.Group, models.BreakoutRoom, int, string) {
+	var group models.Group
+	var room models.BreakoutRoom
+	userId, ok := c.Locals("userId").(string)
+	if !ok {
+		return group, room, 401, "Unauthorized"
+	}
+	gid, err := primitive.ObjectIDFromHex(c.Params("groupId"))
+	if err != nil {
+		return group, room, 400, "Invalid groupId"
+	}
+	rid, err := primitive.ObjectIDFromHex(c.Params("roomId"))
+	if err != nil {
+		return group, room, 400, "Invalid roomId"
+	}
+	group, err = findGroup(ctx, gid)
+	if err != nil {
+		return group, room, 404, "Group not found"
+	}
+	if !canViewGroup(ctx, group, userId) {
+		return group, room, 403, "Not a member of this group"
+	}
+	err = breakoutRoomCol.FindOne(ctx, bson.M{"_id": rid, "groupId": gid}).Decode(&room)
+	if err != nil {
+		return group, room, 404, "Room not found"
+	}
+	return group, room, 0, ""
+}
+
+// POST /api/groups/:groupId/rooms
+func CreateBreakoutRoom(c *fiber.Ctx) error {
+	userId := c.Locals("userId")
+	if userId == nil {
+		return c.Status(401).JSON(fiber.Map{"success": false, "message": "Unauthorized"})
+	}
+	var body struct {
+		Name      string   `json:"name"`
+		MemberIds []string `json:"memberIds"`
+	}
+	if err := c.BodyParser(&body); err != nil || body.Name == "" {
+		return c.Status(400).JSON(fiber.Map{"success": false, "message": "Invalid request"})
+	}
+	gid, err := primitive.ObjectIDFromHex(c.Params("groupId"))
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"success": false, "message": "Invalid groupId"})
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	group, err := findGroup(ctx, gid)
+	if err != nil {
+		return c.Status(404).JSON(fiber.Map{"success": false, "message": "Group not found"})
+	}
+	if !canManageGroup(ctx, group, userId.(string)) {
+		return c.Status(403).JSON(fiber.Map{"success": false, "message": "Only the group leader or an organization admin can manage rooms"})
+	}
+	session, ok := findActiveSession(ctx, gid)
+	if !ok {
+		return c.Status(409).JSON(fiber.Map{"success": false, "message": "Session is not active"})
+	}
+	members, msg := parseMemberIds(group, body.MemberIds)
+	if msg != "" {
+		return c.Status(400).JSON(fiber.Map{"success": false, "message": msg})
+	}
+	room := models.BreakoutRoom{
+		ID:        primitive.NewObjectID(),
+		GroupID:   gid,
+		SessionID: session.ID,
+		Name:      body.Name,
+		Members:   members,
+		CreatedBy: group.LeaderID,
+		CreatedAt: time.Now(),
+	}
+	// Satu anggota hanya boleh berada di satu room
+	if len(members) > 0 {
+		_, err = breakoutRoomCol.UpdateMany(ctx, bson.M{"groupId": gid, "closedAt": nil}, bson.M{"$pull": bson.M{"members": bson.M{"$in": members}}})
+		if err != nil {
+			return c.Status(500).JSON(fiber.Map{"success": false, "message": "Failed to reassign members"})
+		}
+	}
+	if _, err = breakoutRoomCol.InsertOne(ctx, room); err != nil {
+		return c.Status(500).JSON(fiber.Map{"success": false, "message": "Failed to create room"})
+	}
+	return c.JSON(fiber.Map{"success": true, "room": room})
+}
+
+// GET /api/groups/:groupId/rooms
+// Hanya room yang masih terbuka
+func ListBreakoutRooms(c *fiber.Ctx) error {
+	userId, ok := c.Locals("userId").(string)
+	if !ok {
+		return c.Status(401).JSON(fiber.Map{"success": false, "message": "Unauthorized"})
+	}
+	gid, err := primitive.ObjectIDFromHex(c.Params("groupId"))
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"success": false, "message": "Invalid groupId"})
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	group, err := findGroup(ctx, gid)
+	if err != nil {
+		return c.Status(404).JSON(fiber.Map{"success": false, "message": "Group not found"})
+	}
+	if !canViewGroup(ctx, group, userId) {
+		return c.Status(403).JSON(fiber.Map{"success": false, "message": "Not a member of this group"})
+	}
+	cursor, err := breakoutRoomCol.Find(ctx, bson.M{"groupId": gid, "closedAt": nil})
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"success": false, "message": "Failed to fetch rooms"})
+	}
+	rooms := []models.BreakoutRoom{}
+	if err := cursor.All(ctx, &rooms); err != nil {
+		return c.Status(500).JSON(fiber.Map{"success": false, "message": "Failed to decode rooms"})
+	}
+	return c.JSON(fiber.Map{"success": true, "rooms": rooms})
+}
+
+// PUT /api/groups/:groupId/rooms/:roomId/members
+func AssignBreakoutRoomMembers(c *fiber.Ctx) error {
+	userId := c.Locals("userId")
+	if userId == nil {
+		return c.Status(401).JSON(fiber.Map{"success": false, "message": "Unauthorized"})
+	}
+	var body struct {
+		MemberIds []string `json:"memberIds"`
+	}
+	if err := c.BodyParser(&body); err != nil {
+		return c.Status(400).JSON(fiber.Map{"success": false, "message": "Invalid request"})
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	group, room, status, msg := loadRoom(ctx, c)
+	if status != 0 {
+		return c.Status(status).JSON(fiber.Map{"success": false, "message": msg})
+	}
+	if !canManageGroup(ctx, group, userId.(string)) {
+		return c.Status(403).JSON(fiber.Map{"success": false, "message": "Only the group leader or an organization admin can manage rooms"})
+	}
+	if room.ClosedAt != nil {
+		return c.Status(409).JSON(fiber.Map{"success": false, "message": "Room is closed"})
+	}
+	members, msg := parseMemberIds(group, body.MemberIds)
+	if msg != "" {
+		return c.Status(400).JSON(fiber.Map{"success": false, "message": msg})
+	}
+	if len(members) > 0 {
+		_, err := breakoutRoomCol.UpdateMany(ctx, bson.M{"groupId": group.ID, "closedAt": nil, "_id": bson.M{"$ne": room.ID}}, bson.M{"$pull": bson.M{"members": bson.M{"$in": members}}})
+		if err != nil {
+			return c.Status(500).JSON(fiber.Map{"success": false, "message": "Failed to reassign members"})
+		}
+	}
+	_, err := breakoutRoomCol.UpdateOne(ctx, bson.M{"_id": room.ID}, bson.M{"$set": bson.M{"members": members}})
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"success": false, "message": "Failed to update room members"})
+	}
+	room.Members = members
+	return c.JSON(fiber.Map{"success": true, "room": room})
+}
+
+// DELETE /api/groups/:groupId/rooms/:roomId
+// Menutup room, anggotanya kembali ke sesi utama. Ringkasan room dibekukan saat ditutup dan
+// dokumennya baru dihapus saat sesi diarsipkan, sehingga tetap masuk arsip sesi induk.
+func CloseBreakoutRoom(c *fiber.Ctx) error {
+	userId := c.Locals("userId")
+	if userId == nil {
+		return c.Status(401).JSON(fiber.Map{"success": false, "message": "Unauthorized"})
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	group, room, status, msg := loadRoom(ctx, c)
+	if status != 0 {
+		return c.Status(status).JSON(fiber.Map{"success": false, "message": msg})
+	}
+	if !canManageGroup(ctx, group, userId.(string)) {
+		return c.Status(403).JSON(fiber.Map{"success": false, "message": "Only the group leader or an organization admin can manage rooms"})
+	}
+	if room.ClosedAt != nil {
+		return c.Status(409).JSON(fiber.Map{"success": false, "message": "Room is already closed"})
+	}
+	now := time.Now()
+	room.ClosedAt = &now
+	summary, err := summarizeBreakoutRoom(ctx, room)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"success": false, "message": "Failed to summarize room"})
+	}
+	res, err := breakoutRoomCol.UpdateOne(ctx, bson.M{"_id": room.ID, "closedAt": nil}, bson.M{"$set": bson.M{"closedAt": now, "summary": summary}})
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"success": false, "message": "Failed to close room"})
+	}
+	if res.ModifiedCount == 0 {
+		return c.Status(409).JSON(fiber.Map{"success": false, "message": "Room is already closed"})
+	}
+	return c.JSON(fiber.Map{"success": true, "summary": summary})
+}
+
+// GET /api/groups/:groupId/rooms/:roomId/camera-status
+func GetBreakoutRoomCameraStatus(c *fiber.Ctx) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	group, room, status, msg := loadRoom(ctx, c)
+	if status != 0 {
+		return c.Status(status).JSON(fiber.Map{"success": false, "message": msg})
+	}
+	cursor, err := cameraStatusCol.Find(ctx, bson.M{"groupId": group.ID, "userId": bson.M{"$in": room.Members}})
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"success": false, "message": "Failed to fetch camera status"})
+	}
+	statuses := []models.CameraStatus{}
+	if err := cursor.All(ctx, &statuses); err != nil {
+		return c.Status(500).JSON(fiber.Map{"success": false, "message": "Failed to decode camera status"})
+	}
+	return c.JSON(fiber.Map{"success": true, "room": room, "statuses": statuses})
+}
+
+// GET /api/groups/:groupId/rooms/:roomId/detections
+func GetBreakoutRoomDetections(c *fiber.Ctx) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	group, room, status, msg := loadRoom(ctx, c)
+	if status != 0 {
+		return c.Status(status).JSON(fiber.Map{"success": false, "message": msg})
+	}
+	detections, err := roomDetections(ctx, group.ID, room)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"success": false, "message": "Failed to fetch detections"})
+	}
+	average, samples, err := roomEmotionStats(ctx, room)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"success": false, "message": "Failed to compute room emotions"})
+	}
+	return c.JSON(fiber.Map{
+		"success":        true,
+		"room":           room,
+		"detections":     detections,
+		"samples":        samples,
+		"averageEmotion": average,
+	})
+}
+
+func roomDetections(ctx context.Context, groupId primitive.ObjectID, room models.BreakoutRoom) ([]models.Detection, error) {
+	detections := []models.Detection{}
+	if len(room.Members) == 0 {
+		return detections, nil
+	}
+	filter := sessionScopedFilter(groupId, room.SessionID)
+	filter["userId"] = bson.M{"$in": room.Members}
+	cursor, err := detectionCol.Find(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	err = cursor.All(ctx, &detections)
+	return detections, err
+}
+
+// sessionScopedFilter memilih dokumen grup milik sesi tertentu, termasuk dokumen lama tanpa sessionId
+func sessionScopedFilter(groupId, sessionId primitive.ObjectID) bson.M {
+	return bson.M{
+		"groupId": groupId,
+		"$or":     []bson.M{{"sessionId": sessionId}, {"sessionId": bson.M{"$exists": false}}},
+	}
+}
+
+// summarizeBreakoutRooms membuat ringkasan semua room sesi untuk digabung ke arsip sesi induk
+func summarizeBreakoutRooms(ctx context.Context, groupId, sessionId primitive.ObjectID) ([]models.BreakoutRoomSummary, error) {
+	cursor, err := breakoutRoomCol.Find(ctx, sessionScopedFilter(groupId, sessionId))
+	if err != nil {
+		return nil, err
+	}
+	var rooms []models.BreakoutRoom
+	if err := cursor.All(ctx, &rooms); err != nil {
+		return nil, err
+	}
+	summaries := []models.BreakoutRoomSummary{}
+	for _, room := range rooms {
+		// Room yang sudah ditutup memakai ringkasan saat ditutup
+		if room.Summary != nil {
+			summaries = append(summaries, *room.Summary)
+			continue
+		}
+		summary, err := summarizeBreakoutRoom(ctx, room)
+		if err != nil {
+			return nil, err
+		}
+		summaries = append(summaries, summary)
+	}
+	return summaries, nil
+}
+
+// summarizeBreakoutRoom meringkas sampel deteksi anggota satu room selama room dibuka
+func summarizeBreakoutRoom(ctx context.Context, room models.BreakoutRoom) (models.BreakoutRoomSummary, error) {
+	average, samples, err := roomEmotionStats(ctx, room)
+	if err != nil {
+		return models.BreakoutRoomSummary{}, err
+	}
+	return models.BreakoutRoomSummary{
+		RoomID:         room.ID,
+		Name:           room.Name,
+		Members:        room.Members,
+		DetectionCount: samples,
+		AverageEmotion: average,
+		CreatedAt:      room.CreatedAt,
+		ClosedAt:       room.ClosedAt,
+	}, nil
+}
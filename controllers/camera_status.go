@@ -11,6 +11,7 @@ import (
 	"github.com/gofiber/fiber/v2"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
@@ -37,13 +38,41 @@ func UpdateCameraStatus(c *fiber.Ctx) error {
 	if err != nil {
 		return c.Status(400).JSON(fiber.Map{"success": false, "message": "Invalid userId"})
 	}
+	if _, err := findGroup(context.Background(), gid); err != nil {
+		return c.Status(404).JSON(fiber.Map{"success": false, "message": "Group not found"})
+	}
+	// Status kamera dibekukan selama sesi dijeda
+	// Tes kamera sebelum sesi dimulai dilaporkan lewat ruang tunggu, bukan status kamera
+	session, ok := findActiveSession(context.Background(), gid)
+	if !ok {
+		return c.Status(409).JSON(fiber.Map{"success": false, "message": "Session has not started"})
+	}
+	if session.Status == models.SessionStatusPaused {
+		return c.Status(409).JSON(fiber.Map{"success": false, "message": "Session is paused"})
+	}
+	sessionId := session.ID
 	filter := bson.M{"groupId": gid, "userId": uid}
-	update := bson.M{"$set": bson.M{"isActive": req.IsActive, "updatedAt": time.Now()}}
-	opts := options.Update().SetUpsert(true)
-	_, err = cameraStatusCol.UpdateOne(context.Background(), filter, update, opts)
-	if err != nil {
+	set := bson.M{"isActive": req.IsActive, "updatedAt": time.Now()}
+	if !sessionId.IsZero() {
+		set["sessionId"] = sessionId
+	}
+	update := bson.M{"$set": set}
+	// Ambil status sebelumnya untuk mencatat perubahan kamera di laporan kehadiran
+	opts := options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.Before)
+	var previous models.CameraStatus
+	err = cameraStatusCol.FindOneAndUpdate(context.Background(), filter, update, opts).Decode(&previous)
+	if err != nil && err != mongo.ErrNoDocuments {
 		return c.Status(500).JSON(fiber.Map{"success": false, "message": "Failed to update camera status"})
 	}
+	trackSessionParticipant(context.Background(), sessionId, uid, req.IsActive)
+	ensureJoined(context.Background(), sessionId, gid, uid)
+	if previous.IsActive != req.IsActive {
+		event := models.AttendanceCameraOff
+		if req.IsActive {
+			event = models.AttendanceCameraOn
+		}
+		recordAttendance(context.Background(), sessionId, gid, uid, event)
+	}
 	return c.JSON(fiber.Map{"success": true})
 }
 
@@ -59,12 +88,7 @@ func GetCameraStatus(c *fiber.Ctx) error {
 	}
 
 	// Cek status sesi grup
-	db := config.GetDB()
-	group := db.Collection("groups")
-	var groupDoc struct {
-		SessionActive bool `bson:"sessionActive"`
-	}
-	err = group.FindOne(context.Background(), bson.M{"_id": gid}).Decode(&groupDoc)
+	groupDoc, err := findGroup(context.Background(), gid)
 	if err != nil {
 		return c.Status(404).JSON(fiber.Map{"success": false, "message": "Group not found"})
 	}
@@ -83,5 +107,9 @@ func GetCameraStatus(c *fiber.Ctx) error {
 	}
 	// Tambahkan log untuk debug
 	fmt.Println("[DEBUG] GetCameraStatus: sessionActive=", groupDoc.SessionActive, "jumlah status=", len(statuses))
-	return c.JSON(fiber.Map{"success": true, "statuses": statuses})
+	sessionStatus := models.SessionStatusActive
+	if session, ok := findActiveSession(context.Background(), gid); ok {
+		sessionStatus = session.Status
+	}
+	return c.JSON(fiber.Map{"success": true, "statuses": statuses, "sessionId": groupDoc.ActiveSessionID, "sessionStatus": sessionStatus})
 }
@@ -0,0 +1,237 @@
+package controllers
+
+import (
+	"context"
+	"time"
+
+	"sitor-backend/config"
+	"sitor-backend/models"
+
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var consentCol = config.GetDB().Collection("consents")
+
+// knownConsentScopes adalah cakupan yang boleh diminta/diberikan
+var knownConsentScopes = map[string]bool{
+	models.ConsentScopeEmotionDetection: true,
+	models.ConsentScopeEngagement:       true,
+}
+
+// consentPolicyVersion adalah versi kebijakan privasi yang berlaku (CONSENT_POLICY_VERSION)
+func consentPolicyVersion() string {
+	return config.GetEnv("CONSENT_POLICY_VERSION", "1")
+}
+
+// hasConsent mengecek apakah user sudah menyetujui scope untuk sesi pada versi kebijakan saat ini
+func hasConsent(ctx context.Context, sessionId, userId primitive.ObjectID, scope string) bool {
+	count, err := consentCol.CountDocuments(ctx, bson.M{
+		"sessionId":     sessionId,
+		"userId":        userId,
+		"policyVersion": consentPolicyVersion(),
+		"scope":         scope,
+		"withdrawnAt":   nil,
+	})
+	return err == nil && count > 0
+}
+
+// loadGroupMember mengambil grup dari parameter groupId dan memastikan user yang login adalah anggotanya
+func loadGroupMember(ctx context.Context, c *fiber.Ctx) (models.Group, primitive.ObjectID, int, string) {
+	var group models.Group
+	userId := c.Locals("userId")
+	if userId == nil {
+		return group, primitive.NilObjectID, 401, "Unauthorized"
+	}
+	uid, err := primitive.ObjectIDFromHex(userId.(string))
+	if err != nil {
+		return group, uid, 400, "Invalid userId"
+	}
+	gid, err := primitive.ObjectIDFromHex(c.Params("groupId"))
+	if err != nil {
+		return group, uid, 400, "Invalid groupId"
+	}
+	group, err = findGroup(ctx, gid)
+	if err != nil {
+		return group, uid, 404, "Group not found"
+	}
+	if !groupHasMember(group, uid) {
+		return group, uid, 403, "Not a member of this group"
+	}
+	return group, uid, 0, ""
+}
+
+// loadMemberSession seperti loadGroupMember, ditambah sesi grup yang sedang berjalan
+func loadMemberSession(ctx context.Context, c *fiber.Ctx) (models.Group, primitive.ObjectID, models.Session, int, string) {
+	group, uid, status, msg := loadGroupMember(ctx, c)
+	if status != 0 {
+		return group, uid, models.Session{}, status, msg
+	}
+	session, ok := findActiveSession(ctx, group.ID)
+	if !ok {
+		return group, uid, session, 409, "Session is not active"
+	}
+	return group, uid, session, 0, ""
+}
+
+// GET /api/consent/policy
+func GetConsentPolicy(c *fiber.Ctx) error {
+	scopes := []string{}
+	for s := range knownConsentScopes {
+		scopes = append(scopes, s)
+	}
+	return c.JSON(fiber.Map{"success": true, "policyVersion": consentPolicyVersion(), "scopes": scopes})
+}
+
+// POST /api/groups/:groupId/consent
+// Body: {"policyVersion": "1", "scope": ["emotion_detection"]}
+func GrantConsent(c *fiber.Ctx) error {
+	var body struct {
+		PolicyVersion string   `json:"policyVersion"`
+		Scope         []string `json:"scope"`
+	}
+	if err := c.BodyParser(&body); err != nil {
+		return c.Status(400).JSON(fiber.Map{"success": false, "message": "Invalid request"})
+	}
+	if body.PolicyVersion != consentPolicyVersion() {
+		return c.Status(409).JSON(fiber.Map{"success": false, "message": "Consent policy version is outdated", "policyVersion": consentPolicyVersion()})
+	}
+	if len(body.Scope) == 0 {
+		return c.Status(400).JSON(fiber.Map{"success": false, "message": "Scope is required"})
+	}
+	for _, s := range body.Scope {
+		if !knownConsentScopes[s] {
+			return c.Status(400).JSON(fiber.Map{"success": false, "message": "Unknown consent scope: " + s})
+		}
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	group, uid, session, status, msg := loadMemberSession(ctx, c)
+	if status != 0 {
+		return c.Status(status).JSON(fiber.Map{"success": false, "message": msg})
+	}
+	var consent models.Consent
+	opts := options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)
+	err := consentCol.FindOneAndUpdate(ctx,
+		bson.M{"sessionId": session.ID, "userId": uid},
+		bson.M{
+			"$set":   bson.M{"groupId": group.ID, "policyVersion": body.PolicyVersion, "scope": body.Scope, "grantedAt": time.Now()},
+			"$unset": bson.M{"withdrawnAt": ""},
+		}, opts).Decode(&consent)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"success": false, "message": "Failed to save consent"})
+	}
+	return c.JSON(fiber.Map{"success": true, "consent": consent})
+}
+
+// DELETE /api/groups/:groupId/consent
+// Menarik persetujuan, deteksi berikutnya di sesi ini akan ditolak
+func WithdrawConsent(c *fiber.Ctx) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	_, uid, session, status, msg := loadMemberSession(ctx, c)
+	if status != 0 {
+		return c.Status(status).JSON(fiber.Map{"success": false, "message": msg})
+	}
+	res, err := consentCol.UpdateOne(ctx,
+		bson.M{"sessionId": session.ID, "userId": uid, "withdrawnAt": nil},
+		bson.M{"$set": bson.M{"withdrawnAt": time.Now()}})
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"success": false, "message": "Failed to withdraw consent"})
+	}
+	if res.MatchedCount == 0 {
+		return c.Status(404).JSON(fiber.Map{"success": false, "message": "No active consent for this session"})
+	}
+	return c.JSON(fiber.Map{"success": true})
+}
+
+// GET /api/groups/:groupId/consent/me
+func GetMyConsent(c *fiber.Ctx) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	_, uid, session, status, msg := loadMemberSession(ctx, c)
+	if status != 0 {
+		return c.Status(status).JSON(fiber.Map{"success": false, "message": msg})
+	}
+	var consent models.Consent
+	err := consentCol.FindOne(ctx, bson.M{"sessionId": session.ID, "userId": uid}).Decode(&consent)
+	if err == mongo.ErrNoDocuments {
+		return c.JSON(fiber.Map{"success": true, "consent": nil, "policyVersion": consentPolicyVersion()})
+	}
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"success": false, "message": "Failed to fetch consent"})
+	}
+	return c.JSON(fiber.Map{"success": true, "consent": consent, "policyVersion": consentPolicyVersion()})
+}
+
+// GET /api/groups/:groupId/consent/status
+// Untuk leader: daftar anggota yang sudah, belum, atau menarik persetujuan di sesi aktif
+func GetConsentStatus(c *fiber.Ctx) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	group, status, msg := loadManagedGroup(ctx, c, "groupId")
+	if status != 0 {
+		return c.Status(status).JSON(fiber.Map{"success": false, "message": msg})
+	}
+	session, ok := findActiveSession(ctx, group.ID)
+	if !ok {
+		return c.Status(409).JSON(fiber.Map{"success": false, "message": "Session is not active"})
+	}
+	cursor, err := consentCol.Find(ctx, bson.M{"sessionId": session.ID})
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"success": false, "message": "Failed to fetch consents"})
+	}
+	var consents []models.Consent
+	if err := cursor.All(ctx, &consents); err != nil {
+		return c.Status(500).JSON(fiber.Map{"success": false, "message": "Failed to decode consents"})
+	}
+	byUser := map[primitive.ObjectID]models.Consent{}
+	for _, cs := range consents {
+		byUser[cs.UserID] = cs
+	}
+	cursor, err = userCol.Find(ctx, bson.M{"_id": bson.M{"$in": group.Members}})
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"success": false, "message": "Failed to fetch members"})
+	}
+	var users []models.User
+	if err := cursor.All(ctx, &users); err != nil {
+		return c.Status(500).JSON(fiber.Map{"success": false, "message": "Failed to decode members"})
+	}
+	consented, missing, withdrawn := []fiber.Map{}, []fiber.Map{}, []fiber.Map{}
+	policy := consentPolicyVersion()
+	for _, u := range users {
+		item := fiber.Map{"userId": u.ID.Hex(), "name": u.Name, "email": u.Email}
+		cs, ok := byUser[u.ID]
+		switch {
+		case ok && cs.WithdrawnAt != nil:
+			item["withdrawnAt"] = cs.WithdrawnAt
+			withdrawn = append(withdrawn, item)
+		case ok && cs.PolicyVersion == policy:
+			item["grantedAt"] = cs.GrantedAt
+			item["scope"] = cs.Scope
+			consented = append(consented, item)
+		default:
+			missing = append(missing, item)
+		}
+	}
+	return c.JSON(fiber.Map{
+		"success":       true,
+		"sessionId":     session.ID,
+		"policyVersion": policy,
+		"consented":     consented,
+		"notConsented":  missing,
+		"withdrawn":     withdrawn,
+	})
+}
+
+// ensureConsentIndexes memastikan satu catatan persetujuan per user per sesi
+func ensureConsentIndexes(ctx context.Context) error {
+	_, err := consentCol.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "sessionId", Value: 1}, {Key: "userId", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	})
+	return err
+}
@@ -0,0 +1,103 @@
+package controllers
+
+import (
+	"context"
+	"time"
+
+	"sitor-backend/analytics"
+	"sitor-backend/models"
+	"sitor-backend/taxonomy"
+
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// GET /api/groups/:groupId/dashboard?sessionId=&bucket=1m&alpha=0.3
+// Dashboard emosi satu sesi (default sesi aktif): rata-rata, histogram emosi dominan,
+// dan tren per bucket waktu yang dihaluskan (exponential smoothing, alpha 1 = tanpa smoothing)
+func GetGroupDashboard(c *fiber.Ctx) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+	group, status, msg := loadManagedGroup(ctx, c, "groupId")
+	if status != 0 {
+		return c.Status(status).JSON(fiber.Map{"success": false, "message": msg})
+	}
+	var session models.Session
+	if q := c.Query("sessionId"); q != "" {
+		sid, err := primitive.ObjectIDFromHex(q)
+		if err != nil {
+			return c.Status(400).JSON(fiber.Map{"success": false, "message": "Invalid sessionId"})
+		}
+		if err := sessionCol.FindOne(ctx, bson.M{"_id": sid, "groupId": group.ID}).Decode(&session); err != nil {
+			return c.Status(404).JSON(fiber.Map{"success": false, "message": "Session not found"})
+		}
+	} else {
+		var ok bool
+		if session, ok = findActiveSession(ctx, group.ID); !ok {
+			return c.Status(409).JSON(fiber.Map{"success": false, "message": "Session is not active"})
+		}
+	}
+	bucket := time.Minute
+	if q := c.Query("bucket"); q != "" {
+		d, err := time.ParseDuration(q)
+		if err != nil || d < time.Second {
+			return c.Status(400).JSON(fiber.Map{"success": false, "message": "Invalid bucket, use a duration like 1m"})
+		}
+		bucket = d
+	}
+	alpha := 1.0
+	if q := c.Query("alpha"); q != "" {
+		alpha = c.QueryFloat("alpha", 1)
+		if alpha <= 0 || alpha > 1 {
+			return c.Status(400).JSON(fiber.Map{"success": false, "message": "alpha must be in (0, 1]"})
+		}
+	}
+
+	tax := taxonomy.Current()
+	labels := tax.Labels()
+	match := bson.M{"meta.sessionId": session.ID}
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: match}},
+		{{Key: "$facet", Value: bson.M{
+			"stats":     bson.A{bson.M{"$group": analytics.AverageGroup(nil, "emotions", labels)}},
+			"histogram": bson.A{bson.M{"$group": analytics.HistogramGroup("emotions", tax.Categories)}},
+			"series": bson.A{
+				bson.M{"$group": analytics.AverageGroup(analytics.BucketExpr("timestamp", bucket), "emotions", labels)},
+				bson.M{"$sort": bson.M{"_id": 1}},
+			},
+		}}},
+	}
+	cursor, err := detectionSampleCol.Aggregate(ctx, pipeline)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"success": false, "message": "Failed to compute dashboard"})
+	}
+	var facets []struct {
+		Stats     []bson.M `bson:"stats"`
+		Histogram []bson.M `bson:"histogram"`
+		Series    []bson.M `bson:"series"`
+	}
+	if err := cursor.All(ctx, &facets); err != nil || len(facets) == 0 {
+		return c.Status(500).JSON(fiber.Map{"success": false, "message": "Failed to decode dashboard"})
+	}
+	f := facets[0]
+	average := models.Emotion{}
+	samples := 0
+	if len(f.Stats) > 0 {
+		average = analytics.EmotionFromDoc(f.Stats[0], labels)
+		samples = analytics.Count(f.Stats[0])
+	}
+	dominant, _ := analytics.Dominant(average, tax.Categories)
+	return c.JSON(fiber.Map{
+		"success":   true,
+		"sessionId": session.ID,
+		"status":    session.Status,
+		"samples":   samples,
+		"average":   average,
+		"dominant":  dominant,
+		"histogram": analytics.HistogramFromDocs(f.Histogram, tax.Categories),
+		"bucket":    bucket.String(),
+		"series":    analytics.Smooth(analytics.PointsFromDocs(f.Series, labels), alpha),
+	})
+}
@@ -9,7 +9,6 @@ import (
 	"github.com/gofiber/fiber/v2"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
-	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
 var detectionCol = config.GetDB().Collection("detections")
@@ -23,106 +22,46 @@ func CreateDetection(c *fiber.Ctx) error {
 		}
 	}()
 	var body struct {
-		GroupId     string         `json:"groupId"`
-		Emotion     string         `json:"emotion"`
-		Probability float64        `json:"probability"`
-		Emotions    models.Emotion `json:"emotions"`
-	}
-	userId := c.Locals("userId")
-	if userId == nil {
-		return c.Status(401).JSON(fiber.Map{"success": false, "message": "Unauthorized: userId missing"})
-	}
-	userIdStr, ok := userId.(string)
-	if !ok {
-		return c.Status(401).JSON(fiber.Map{"success": false, "message": "Unauthorized: userId not string"})
+		GroupId     string                   `json:"groupId"`
+		Emotion     string                   `json:"emotion"`
+		Probability *float64                 `json:"probability"`
+		Emotions    map[string]float64       `json:"emotions"`
+		Signals     *models.DetectionSignals `json:"signals"`
+		// SampleID opsional; request ulang dengan sampleId sama dijawab dengan hasil pertama (lihat middleware.Idempotent)
+		SampleID string `json:"sampleId"`
 	}
 	if err := c.BodyParser(&body); err != nil {
-		return c.Status(400).JSON(fiber.Map{"success": false, "message": "Invalid request"})
+		return c.Status(400).JSON(newDetectionError(400, detectionErrInvalidBody, "Invalid request", "").response())
 	}
-	objGroupId, err := primitive.ObjectIDFromHex(body.GroupId)
-	if err != nil {
-		return c.Status(400).JSON(fiber.Map{"success": false, "message": "Invalid groupId"})
+	target, derr := resolveDetectionTarget(c.Context(), c.Locals("userId"), body.GroupId)
+	if derr != nil {
+		return c.Status(derr.Status).JSON(derr.response())
 	}
-	objUserId, err := primitive.ObjectIDFromHex(userIdStr)
-	if err != nil {
-		return c.Status(400).JSON(fiber.Map{"success": false, "message": "Invalid userId"})
+	if body.Probability != nil && (*body.Probability < 0 || *body.Probability > 1) {
+		derr := newDetectionError(422, detectionErrOutOfRange, "Probability must be between 0 and 1", "probability")
+		return c.Status(derr.Status).JSON(derr.response())
 	}
-	// Ambil tanggal hari ini dalam format YYYY-MM-DD
-	dateStr := time.Now().Format("2006-01-02")
-	col := config.GetDB().Collection("detections")
-	// Ambil nama user dari database
-	userCol := config.GetDB().Collection("users")
-	var user models.User
-	err = userCol.FindOne(c.Context(), bson.M{"_id": objUserId}).Decode(&user)
-	if err != nil {
-		log.Printf("[CreateDetection] Failed to get user name: %v", err)
-		return c.Status(500).JSON(fiber.Map{"success": false, "message": "Failed to get user name"})
+	emotions, derr := validateEmotionPayload(body.Emotions, body.Emotion, "")
+	if derr != nil {
+		return c.Status(derr.Status).JSON(derr.response())
 	}
-	// Tambahkan log debug setiap request deteksi masuk
-	log.Printf("[CreateDetection] groupId=%s userId=%s emotions=%+v", body.GroupId, userIdStr, body.Emotions)
-	// Upsert: hanya satu data deteksi per user per grup
-	filter := bson.M{
-		"groupId": objGroupId,
-		"userId":  objUserId,
+	if derr := validateSignals(target, body.Signals, ""); derr != nil {
+		return c.Status(derr.Status).JSON(derr.response())
 	}
-	if body.Emotions != (models.Emotion{}) {
-		update := bson.M{
-			"$set": bson.M{
-				"groupId":   objGroupId,
-				"userId":    objUserId,
-				"userName":  user.Name,
-				"timestamp": time.Now(),
-				"date":      dateStr,
-				"emotions":  body.Emotions,
-			},
-		}
-		opts := options.Update().SetUpsert(true)
-		_, err = col.UpdateOne(c.Context(), filter, update, opts)
-		if err != nil {
-			log.Printf("[CreateDetection] Failed to upsert detection: %v", err)
-			return c.Status(500).JSON(fiber.Map{"success": false, "message": "Failed to save detection"})
-		}
-		return c.JSON(fiber.Map{"success": true})
-	}
-	// fallback: jika tidak ada field emotions, gunakan logika lama (1/0)
-	emotionUpdate := bson.M{
-		"neutral":   0,
-		"happy":     0,
-		"sad":       0,
-		"angry":     0,
-		"surprised": 0,
-		"disgusted": 0,
-	}
-	switch body.Emotion {
-	case "neutral":
-		emotionUpdate["neutral"] = 1
-	case "happy":
-		emotionUpdate["happy"] = 1
-	case "sad":
-		emotionUpdate["sad"] = 1
-	case "angry":
-		emotionUpdate["angry"] = 1
-	case "surprised":
-		emotionUpdate["surprised"] = 1
-	case "disgusted":
-		emotionUpdate["disgusted"] = 1
-	}
-	update := bson.M{
-		"$set": bson.M{
-			"groupId":   objGroupId,
-			"userId":    objUserId,
-			"userName":  user.Name,
-			"timestamp": time.Now(),
-			"date":      dateStr,
-			"emotions":  emotionUpdate,
-		},
+	if len(body.SampleID) > maxSampleIDLength {
+		derr := newDetectionError(422, detectionErrInvalidSampleID, "sampleId is too long", "sampleId")
+		return c.Status(derr.Status).JSON(derr.response())
 	}
-	opts := options.Update().SetUpsert(true)
-	_, err = col.UpdateOne(c.Context(), filter, update, opts)
-	if err != nil {
-		log.Printf("[CreateDetection] Failed to upsert detection: %v", err)
+	// Tambahkan log debug setiap request deteksi masuk
+	log.Printf("[CreateDetection] groupId=%s userId=%s emotions=%+v", body.GroupId, target.UserID.Hex(), emotions)
+	trackSessionParticipant(c.Context(), target.Session.ID, target.UserID, true)
+	sample := newDetectionSample(target, emotions, body.Signals, time.Now())
+	sample.SampleID = body.SampleID
+	if err := storeDetection(c.Context(), target, sample); err != nil {
+		log.Printf("[CreateDetection] Failed to save detection: %v", err)
 		return c.Status(500).JSON(fiber.Map{"success": false, "message": "Failed to save detection"})
 	}
+	evaluateAlertsAsync(target)
 	return c.JSON(fiber.Map{"success": true})
 }
 
@@ -139,8 +78,12 @@ func GetDetectionsByGroup(c *fiber.Ctx) error {
 	if err != nil {
 		return c.Status(400).JSON(fiber.Map{"success": false, "message": "Invalid groupId"})
 	}
-	col := config.GetDB().Collection("detections")
-	cursor, err := col.Find(c.Context(), bson.M{"groupId": objGroupId})
+	// Hanya deteksi sesi yang sedang berjalan; deteksi sesi sebelumnya menunggu diarsipkan
+	filter := bson.M{"groupId": objGroupId, "sessionId": bson.M{"$exists": false}}
+	if sid := activeSessionID(c.Context(), objGroupId); !sid.IsZero() {
+		filter["sessionId"] = sid
+	}
+	cursor, err := detectionCol.Find(c.Context(), filter)
 	if err != nil {
 		log.Printf("[GetDetectionsByGroup] Failed to fetch detections: %v", err)
 		return c.Status(500).JSON(fiber.Map{"success": false, "message": "Failed to fetch detections"})
@@ -0,0 +1,261 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"sitor-backend/config"
+	"sitor-backend/models"
+
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var sampleIdCol = config.GetDB().Collection("detection_sample_ids")
+
+// batchSample adalah satu sampel di dalam POST /api/detections/batch
+type batchSample struct {
+	Seq       int64                    `json:"seq"`
+	Timestamp time.Time                `json:"timestamp"`
+	Emotion   string                   `json:"emotion"`
+	Emotions  map[string]float64       `json:"emotions"`
+	Signals   *models.DetectionSignals `json:"signals"`
+	SampleID  string                   `json:"sampleId"`
+}
+
+// batchSampleResult adalah hasil penerimaan per sampel
+type batchSampleResult struct {
+	Index    int   `json:"index"`
+	Seq      int64 `json:"seq"`
+	Accepted bool  `json:"accepted"`
+	// Duplicate true jika sampel dengan sampleId yang sama sudah tersimpan sebelumnya (tidak disimpan ulang)
+	Duplicate bool   `json:"duplicate,omitempty"`
+	Code      string `json:"code,omitempty"`
+	Field     string `json:"field,omitempty"`
+	Error     string `json:"error,omitempty"`
+}
+
+// POST /api/detections/batch
+// Body: {"groupId": "...", "sentAt": "2024-01-01T08:00:05Z", "samples": [{"seq": 1, "timestamp": "2024-01-01T08:00:00.250Z", "emotions": {...}}]}
+// Timestamp sampel memakai jam client. sentAt (opsional) adalah jam client saat mengirim,
+// dipakai untuk menolak batch dari client yang jamnya terlalu melenceng.
+// sampleId (opsional) per sampel mencegah sampel tersimpan ganda saat batch dikirim ulang.
+func CreateDetectionBatch(c *fiber.Ctx) error {
+	var body struct {
+		GroupId string        `json:"groupId"`
+		SentAt  *time.Time    `json:"sentAt"`
+		Samples []batchSample `json:"samples"`
+	}
+	if err := c.BodyParser(&body); err != nil {
+		return c.Status(400).JSON(newDetectionError(400, detectionErrInvalidBody, "Invalid request", "").response())
+	}
+	maxBatch := config.GetEnvInt("DETECTION_BATCH_MAX", 500)
+	if len(body.Samples) == 0 {
+		return c.Status(400).JSON(newDetectionError(400, detectionErrInvalidBody, "samples is required", "samples").response())
+	}
+	if len(body.Samples) > maxBatch {
+		derr := newDetectionError(413, detectionErrInvalidBody, "Too many samples in one batch", "samples")
+		derr.Details = fiber.Map{"max": maxBatch}
+		return c.Status(derr.Status).JSON(derr.response())
+	}
+	maxSkew := config.GetEnvDuration("DETECTION_MAX_CLOCK_SKEW", 30*time.Second)
+	maxAge := config.GetEnvDuration("DETECTION_MAX_SAMPLE_AGE", 10*time.Minute)
+	now := time.Now()
+	if body.SentAt != nil {
+		if skew := now.Sub(*body.SentAt); skew > maxSkew || skew < -maxSkew {
+			derr := newDetectionError(422, detectionErrInvalidTimestamp, "Client clock skew too large", "sentAt")
+			derr.Details = fiber.Map{"skewMs": skew.Milliseconds()}
+			return c.Status(derr.Status).JSON(derr.response())
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+	target, derr := resolveDetectionTarget(ctx, c.Locals("userId"), body.GroupId)
+	if derr != nil {
+		return c.Status(derr.Status).JSON(derr.response())
+	}
+
+	results := make([]batchSampleResult, len(body.Samples))
+	samples := []models.DetectionSample{}
+	sampleIndex := []int{}
+	seen := map[int64]bool{}
+	seenIDs := map[string]bool{}
+	for i, s := range body.Samples {
+		results[i] = batchSampleResult{Index: i, Seq: s.Seq}
+		field := fmt.Sprintf("samples[%d].", i)
+		var verr *detectionError
+		switch {
+		case s.Seq <= 0:
+			verr = newDetectionError(422, detectionErrInvalidSeq, "seq must be a positive number", field+"seq")
+		case seen[s.Seq]:
+			verr = newDetectionError(422, detectionErrInvalidSeq, "duplicate seq in batch", field+"seq")
+		case len(s.SampleID) > maxSampleIDLength:
+			verr = newDetectionError(422, detectionErrInvalidSampleID, "sampleId is too long", field+"sampleId")
+		case s.Timestamp.IsZero():
+			verr = newDetectionError(422, detectionErrInvalidTimestamp, "timestamp is required", field+"timestamp")
+		case s.Timestamp.After(now.Add(maxSkew)):
+			verr = newDetectionError(422, detectionErrInvalidTimestamp, "timestamp is in the future", field+"timestamp")
+		case s.Timestamp.Before(now.Add(-maxAge)):
+			verr = newDetectionError(422, detectionErrInvalidTimestamp, "timestamp is too old", field+"timestamp")
+		case !target.Session.ID.IsZero() && s.Timestamp.Before(target.Session.StartedAt.Add(-maxSkew)):
+			verr = newDetectionError(422, detectionErrInvalidTimestamp, "timestamp is before session start", field+"timestamp")
+		}
+		emotions := models.Emotion{}
+		if verr == nil {
+			emotions, verr = validateEmotionPayload(s.Emotions, s.Emotion, field)
+		}
+		if verr == nil {
+			verr = validateSignals(target, s.Signals, field)
+		}
+		if verr != nil {
+			results[i].Code = verr.Code
+			results[i].Field = verr.Field
+			results[i].Error = verr.Message
+			continue
+		}
+		seen[s.Seq] = true
+		results[i].Accepted = true
+		if s.SampleID != "" {
+			if seenIDs[s.SampleID] {
+				results[i].Duplicate = true
+				continue
+			}
+			seenIDs[s.SampleID] = true
+		}
+		sample := newDetectionSample(target, emotions, s.Signals, s.Timestamp)
+		sample.Seq = s.Seq
+		sample.SampleID = s.SampleID
+		samples = append(samples, sample)
+		sampleIndex = append(sampleIndex, i)
+	}
+
+	// sampleId yang sudah dipesan request lain (mis. batch yang sama dikirim ulang bersamaan) tidak disimpan lagi
+	duplicate, err := reserveSampleIDs(ctx, target, samples)
+	if err != nil {
+		log.Printf("[CreateDetectionBatch] Failed to reserve sample IDs: %v", err)
+		return c.Status(500).JSON(fiber.Map{"success": false, "message": "Failed to check sample IDs"})
+	}
+	toInsert := []interface{}{}
+	insertIndex := []int{}
+	latest := -1
+	var latestSample models.DetectionSample
+	for j, sample := range samples {
+		i := sampleIndex[j]
+		if duplicate[sample.SampleID] {
+			results[i].Duplicate = true
+			continue
+		}
+		toInsert = append(toInsert, sample)
+		insertIndex = append(insertIndex, i)
+		if latest < 0 || sample.Timestamp.After(latestSample.Timestamp) {
+			latest = i
+			latestSample = sample
+		}
+	}
+
+	if len(toInsert) > 0 {
+		_, err := detectionSampleCol.InsertMany(ctx, toInsert, options.InsertMany().SetOrdered(false))
+		if err != nil {
+			log.Printf("[CreateDetectionBatch] Failed to insert samples: %v", err)
+			failed := map[int]bool{}
+			if bwe, ok := err.(mongo.BulkWriteException); ok {
+				for _, we := range bwe.WriteErrors {
+					failed[we.Index] = true
+				}
+			}
+			released := []string{}
+			for j, i := range insertIndex {
+				// Tanpa detail per sampel, anggap semua sampel gagal disimpan
+				if len(failed) == 0 || failed[j] {
+					results[i].Accepted = false
+					results[i].Error = "failed to store sample"
+					if id := body.Samples[i].SampleID; id != "" {
+						released = append(released, id)
+					}
+				}
+			}
+			// Sampel yang gagal disimpan boleh dikirim ulang dengan sampleId yang sama
+			releaseSampleIDs(ctx, target, released)
+		}
+		if latest >= 0 && results[latest].Accepted {
+			if err := updateLatestDetection(ctx, target, latestSample); err != nil {
+				log.Printf("[CreateDetectionBatch] Failed to update latest detection: %v", err)
+			}
+		}
+		trackSessionParticipant(ctx, target.Session.ID, target.UserID, true)
+		evaluateAlertsAsync(target)
+	}
+
+	accepted, duplicates := 0, 0
+	for _, r := range results {
+		if r.Accepted {
+			accepted++
+		}
+		if r.Duplicate {
+			duplicates++
+		}
+	}
+	return c.JSON(fiber.Map{
+		"success":    true,
+		"accepted":   accepted,
+		"duplicates": duplicates,
+		"rejected":   len(results) - accepted,
+		"results":    results,
+	})
+}
+
+// reserveSampleIDs memesan sampleId di koleksi detection_sample_ids (unique index) sebelum sampel disimpan.
+// Koleksi time series tidak mendukung unique index, jadi pemesanan ini yang mencegah dua request
+// bersamaan menyimpan sampel yang sama. Mengembalikan sampleId yang sudah dipesan sebelumnya.
+func reserveSampleIDs(ctx context.Context, target detectionTarget, samples []models.DetectionSample) (map[string]bool, error) {
+	duplicate := map[string]bool{}
+	docs := []interface{}{}
+	ids := []string{}
+	now := time.Now()
+	for _, s := range samples {
+		if s.SampleID != "" {
+			docs = append(docs, bson.M{"userId": target.UserID, "sampleId": s.SampleID, "createdAt": now})
+			ids = append(ids, s.SampleID)
+		}
+	}
+	if len(docs) == 0 {
+		return duplicate, nil
+	}
+	_, err := sampleIdCol.InsertMany(ctx, docs, options.InsertMany().SetOrdered(false))
+	if bwe, ok := err.(mongo.BulkWriteException); ok {
+		for _, we := range bwe.WriteErrors {
+			if we.Code != 11000 {
+				return nil, err
+			}
+			duplicate[ids[we.Index]] = true
+		}
+		return duplicate, nil
+	}
+	return duplicate, err
+}
+
+// releaseSampleIDs membatalkan pemesanan sampleId yang sampelnya gagal disimpan
+func releaseSampleIDs(ctx context.Context, target detectionTarget, ids []string) {
+	if len(ids) == 0 {
+		return
+	}
+	if _, err := sampleIdCol.DeleteMany(ctx, bson.M{"userId": target.UserID, "sampleId": bson.M{"$in": ids}}); err != nil {
+		log.Printf("[CreateDetectionBatch] Failed to release sample IDs: %v", err)
+	}
+}
+
+// ensureSampleIDIndexes membuat unique index pemesanan sampleId per user. Pemesanan kedaluwarsa
+// setelah DETECTION_MAX_SAMPLE_AGE ditambah toleransi jam, karena sampel yang lebih tua sudah ditolak.
+func ensureSampleIDIndexes(ctx context.Context) error {
+	ttl := config.GetEnvDuration("DETECTION_MAX_SAMPLE_AGE", 10*time.Minute) + config.GetEnvDuration("DETECTION_MAX_CLOCK_SKEW", 30*time.Second)
+	_, err := sampleIdCol.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "userId", Value: 1}, {Key: "sampleId", Value: 1}}, Options: options.Index().SetUnique(true)},
+		{Keys: bson.D{{Key: "createdAt", Value: 1}}, Options: options.Index().SetExpireAfterSeconds(int32(ttl.Seconds()))},
+	})
+	return err
+}
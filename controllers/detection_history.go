@@ -2,15 +2,19 @@ package controllers
 
 import (
 	"context"
+	"sitor-backend/analytics"
 	"sitor-backend/config"
+	"sitor-backend/taxonomy"
 	"time"
 
 	"github.com/gofiber/fiber/v2"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
 )
 
 // GET /api/groups/:groupId/history
+// Setiap arsip sesi disertai ringkasan emosi dan marker timeline agar grafik bisa menampilkannya
 func GetDetectionHistory(c *fiber.Ctx) error {
 	groupId := c.Params("groupId")
 	if groupId == "" {
@@ -23,7 +27,28 @@ func GetDetectionHistory(c *fiber.Ctx) error {
 	db := config.GetDB()
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
-	cursor, err := db.Collection("detection_history").Find(ctx, bson.M{"groupId": objGroupId})
+	if _, err := findGroup(ctx, objGroupId); err != nil {
+		return c.Status(404).JSON(fiber.Map{"success": false, "message": "Group not found"})
+	}
+	tax := taxonomy.Current()
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"groupId": objGroupId}}},
+		// Ringkasan per arsip: rata-rata emosi semua peserta dan emosi dominannya
+		{{Key: "$addFields", Value: bson.M{"summary": bson.M{
+			"average": analytics.AverageFields("detections.emotions", tax.Labels()),
+		}}}},
+		{{Key: "$addFields", Value: bson.M{"summary.dominant": analytics.DominantExpr("summary.average", tax.Categories)}}},
+		{{Key: "$lookup", Value: bson.M{
+			"from": "session_markers",
+			"let":  bson.M{"sid": "$sessionId"},
+			"pipeline": bson.A{
+				bson.M{"$match": bson.M{"$expr": bson.M{"$eq": bson.A{"$sessionId", "$$sid"}}}},
+				bson.M{"$sort": bson.M{"at": 1}},
+			},
+			"as": "markers",
+		}}},
+	}
+	cursor, err := db.Collection("detection_history").Aggregate(ctx, pipeline)
 	if err != nil {
 		return c.Status(500).JSON(fiber.Map{"success": false, "message": "Failed to fetch history", "error": err.Error()})
 	}
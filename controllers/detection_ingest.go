@@ -0,0 +1,75 @@
+package controllers
+
+import (
+	"context"
+	"log"
+
+	"sitor-backend/models"
+
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// detectionTarget adalah user, grup, dan sesi tujuan deteksi yang sudah lolos pengecekan
+type detectionTarget struct {
+	GroupID  primitive.ObjectID
+	UserID   primitive.ObjectID
+	UserName string
+	Session  models.Session
+	// SignalsAllowed true jika user menyetujui pengumpulan sinyal perhatian
+	SignalsAllowed bool
+}
+
+// resolveDetectionTarget melakukan pengecekan yang sama untuk deteksi tunggal maupun batch:
+// user anggota grup, sesi grup berjalan dan tidak dijeda, serta user sudah memberi persetujuan.
+func resolveDetectionTarget(ctx context.Context, userId interface{}, groupId string) (detectionTarget, *detectionError) {
+	var target detectionTarget
+	userIdStr, ok := userId.(string)
+	if !ok {
+		return target, newDetectionError(401, detectionErrUnauthorized, "Unauthorized: userId missing", "")
+	}
+	objUserId, err := primitive.ObjectIDFromHex(userIdStr)
+	if err != nil {
+		return target, newDetectionError(401, detectionErrUnauthorized, "Invalid userId", "")
+	}
+	objGroupId, err := primitive.ObjectIDFromHex(groupId)
+	if err != nil {
+		return target, newDetectionError(400, detectionErrInvalidGroupID, "Invalid groupId", "groupId")
+	}
+	group, err := findGroup(ctx, objGroupId)
+	if err != nil {
+		return target, newDetectionError(404, detectionErrGroupNotFound, "Group not found", "groupId")
+	}
+	if !groupHasMember(group, objUserId) {
+		return target, newDetectionError(403, detectionErrNotMember, "Not a member of this group", "groupId")
+	}
+	// Ambil nama user dari database
+	var user models.User
+	if err := userCol.FindOne(ctx, bson.M{"_id": objUserId}).Decode(&user); err != nil {
+		log.Printf("[Detection] Failed to get user name: %v", err)
+		return target, newDetectionError(401, detectionErrUnauthorized, "User not found", "")
+	}
+	// Deteksi sebelum sesi dimulai (mis. saat masih di ruang tunggu) atau setelah sesi berakhir ditolak.
+	// Sesi lama tanpa dokumen sessions sudah dibuatkan dokumennya oleh findActiveSession.
+	session, ok := findActiveSession(ctx, objGroupId)
+	if !ok {
+		return target, newDetectionError(409, detectionErrSessionInactive, "Session is not active", "")
+	}
+	// Sesi yang dijeda tidak menerima deteksi baru
+	if session.Status == models.SessionStatusPaused {
+		return target, newDetectionError(409, detectionErrSessionPaused, "Session is paused", "")
+	}
+	// Deteksi dalam sesi hanya diterima jika user sudah memberi persetujuan
+	if !hasConsent(ctx, session.ID, objUserId, models.ConsentScopeEmotionDetection) {
+		derr := newDetectionError(403, detectionErrConsentRequired, "Consent required for emotion detection", "")
+		derr.Details = fiber.Map{"policyVersion": consentPolicyVersion()}
+		return target, derr
+	}
+	target.GroupID = objGroupId
+	target.UserID = objUserId
+	target.UserName = user.Name
+	target.Session = session
+	target.SignalsAllowed = hasConsent(ctx, session.ID, objUserId, models.ConsentScopeEngagement)
+	return target, nil
+}
@@ -0,0 +1,182 @@
+package controllers
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"sitor-backend/config"
+	"sitor-backend/models"
+
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var detectionSampleCol = config.GetDB().Collection("detection_samples")
+
+// ensureDetectionSamplesCollection membuat koleksi time series detection_samples jika belum ada.
+// DETECTION_SAMPLE_RETENTION (mis. "2160h") mengatur kapan sampel lama dihapus otomatis, 0 berarti disimpan selamanya.
+func ensureDetectionSamplesCollection(ctx context.Context) error {
+	db := config.GetDB()
+	names, err := db.ListCollectionNames(ctx, bson.M{"name": "detection_samples"})
+	if err != nil {
+		return err
+	}
+	if len(names) > 0 {
+		return nil
+	}
+	tsOpts := options.TimeSeries().SetTimeField("timestamp").SetMetaField("meta").SetGranularity("seconds")
+	opts := options.CreateCollection().SetTimeSeriesOptions(tsOpts)
+	if retention := config.GetEnvDuration("DETECTION_SAMPLE_RETENTION", 0); retention > 0 {
+		opts.SetExpireAfterSeconds(int64(retention.Seconds()))
+	}
+	if err := db.CreateCollection(ctx, "detection_samples", opts); err != nil {
+		return err
+	}
+	_, err = detectionSampleCol.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "meta.sessionId", Value: 1}, {Key: "meta.userId", Value: 1}, {Key: "timestamp", Value: 1}},
+	})
+	return err
+}
+
+// newDetectionSample membuat sampel time series untuk target deteksi.
+// Skor engagement dihitung di sini jika client mengirim sinyal perhatian.
+func newDetectionSample(target detectionTarget, emotions models.Emotion, signals *models.DetectionSignals, at time.Time) models.DetectionSample {
+	return models.DetectionSample{
+		Timestamp:  at,
+		Meta:       models.DetectionSampleMeta{GroupID: target.GroupID, UserID: target.UserID, SessionID: target.Session.ID},
+		Emotions:   emotions,
+		Signals:    signals,
+		Engagement: engagementScore(signals),
+		ReceivedAt: time.Now(),
+	}
+}
+
+// storeDetection menyimpan satu sampel deteksi ke time series, lalu memperbarui data terakhir
+// user di koleksi detections (dipakai GetDetectionsByGroup dan arsip sesi).
+// Error hanya dikembalikan jika sampel gagal disimpan; kegagalan data terakhir cukup dicatat,
+// sama seperti batch, agar client tidak mengulang request dan sampel tersimpan dua kali.
+func storeDetection(ctx context.Context, target detectionTarget, sample models.DetectionSample) error {
+	if _, err := detectionSampleCol.InsertOne(ctx, sample); err != nil {
+		return err
+	}
+	if err := updateLatestDetection(ctx, target, sample); err != nil {
+		log.Printf("[DETECTION] failed to update latest detection for user %s: %v", target.UserID.Hex(), err)
+	}
+	return nil
+}
+
+// updateLatestDetection meng-upsert data deteksi terakhir user di grup.
+// Sampel yang lebih lama dari data terakhir (mis. dari batch yang terlambat) tidak menimpanya.
+func updateLatestDetection(ctx context.Context, target detectionTarget, sample models.DetectionSample) error {
+	// Upsert: hanya satu data deteksi per user per sesi. sessionId ikut di filter agar sesi baru
+	// tidak menimpa deteksi terakhir sesi sebelumnya yang arsipnya masih pending.
+	filter := bson.M{"groupId": target.GroupID, "userId": target.UserID, "sessionId": bson.M{"$exists": false}}
+	if !target.Session.ID.IsZero() {
+		filter["sessionId"] = target.Session.ID
+	}
+	var existing models.Detection
+	if err := detectionCol.FindOne(ctx, filter).Decode(&existing); err == nil && existing.Timestamp.After(sample.Timestamp) {
+		return nil
+	}
+	set := bson.M{
+		"groupId":   target.GroupID,
+		"userId":    target.UserID,
+		"userName":  target.UserName,
+		"timestamp": sample.Timestamp,
+		"date":      sample.Timestamp.Format("2006-01-02"),
+		"emotions":  sample.Emotions,
+	}
+	update := bson.M{"$set": set}
+	// Sinyal dari sampel sebelumnya tidak boleh tertinggal jika sampel ini tidak membawa sinyal
+	if sample.Signals != nil {
+		set["signals"] = sample.Signals
+		set["engagement"] = sample.Engagement
+	} else {
+		update["$unset"] = bson.M{"signals": "", "engagement": ""}
+	}
+	if !target.Session.ID.IsZero() {
+		set["sessionId"] = target.Session.ID
+	}
+	_, err := detectionCol.UpdateOne(ctx, filter, update, options.Update().SetUpsert(true))
+	return err
+}
+
+// deleteGroupSamples menghapus semua sampel grup. Koleksi time series tidak bisa ditulis
+// di dalam transaksi, jadi dipanggil terpisah sebelum purge grup.
+func deleteGroupSamples(ctx context.Context, groupId primitive.ObjectID) error {
+	_, err := detectionSampleCol.DeleteMany(ctx, bson.M{"meta.groupId": groupId})
+	return err
+}
+
+// GET /api/sessions/:id/samples?userId=&from=&to=&limit=1000
+// Leader melihat semua sampel sesi, anggota hanya sampel miliknya sendiri
+func GetSessionSamples(c *fiber.Ctx) error {
+	userId := c.Locals("userId")
+	if userId == nil {
+		return c.Status(401).JSON(fiber.Map{"success": false, "message": "Unauthorized"})
+	}
+	uid, err := primitive.ObjectIDFromHex(userId.(string))
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"success": false, "message": "Invalid userId"})
+	}
+	sid, err := primitive.ObjectIDFromHex(c.Params("id"))
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"success": false, "message": "Invalid sessionId"})
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+	var session models.Session
+	if err := sessionCol.FindOne(ctx, bson.M{"_id": sid}).Decode(&session); err != nil {
+		return c.Status(404).JSON(fiber.Map{"success": false, "message": "Session not found"})
+	}
+	group, err := findGroup(ctx, session.GroupID)
+	if err != nil {
+		return c.Status(404).JSON(fiber.Map{"success": false, "message": "Group not found"})
+	}
+	filter := bson.M{"meta.sessionId": sid}
+	if canManageGroup(ctx, group, userId.(string)) {
+		if q := c.Query("userId"); q != "" {
+			target, err := primitive.ObjectIDFromHex(q)
+			if err != nil {
+				return c.Status(400).JSON(fiber.Map{"success": false, "message": "Invalid userId filter"})
+			}
+			filter["meta.userId"] = target
+		}
+	} else if groupHasMember(group, uid) {
+		filter["meta.userId"] = uid
+	} else {
+		return c.Status(403).JSON(fiber.Map{"success": false, "message": "Not a member of this group"})
+	}
+	timeFilter := bson.M{}
+	for param, op := range map[string]string{"from": "$gte", "to": "$lte"} {
+		if q := c.Query(param); q != "" {
+			t, err := time.Parse(time.RFC3339, q)
+			if err != nil {
+				return c.Status(400).JSON(fiber.Map{"success": false, "message": "Invalid " + param + ", use RFC3339"})
+			}
+			timeFilter[op] = t
+		}
+	}
+	if len(timeFilter) > 0 {
+		filter["timestamp"] = timeFilter
+	}
+	limit := c.QueryInt("limit", 1000)
+	if limit < 1 || limit > 10000 {
+		limit = 1000
+	}
+	opts := options.Find().SetSort(bson.D{{Key: "timestamp", Value: 1}}).SetLimit(int64(limit))
+	cursor, err := detectionSampleCol.Find(ctx, filter, opts)
+	if err != nil {
+		log.Printf("[GetSessionSamples] Failed to fetch samples: %v", err)
+		return c.Status(500).JSON(fiber.Map{"success": false, "message": "Failed to fetch samples"})
+	}
+	samples := []models.DetectionSample{}
+	if err := cursor.All(ctx, &samples); err != nil {
+		return c.Status(500).JSON(fiber.Map{"success": false, "message": "Failed to decode samples"})
+	}
+	return c.JSON(fiber.Map{"success": true, "sessionId": sid, "samples": samples})
+}
@@ -0,0 +1,143 @@
+package controllers
+
+import (
+	"fmt"
+	"math"
+
+	"sitor-backend/config"
+	"sitor-backend/models"
+	"sitor-backend/taxonomy"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Kode error deteksi yang dikirim ke client agar bisa ditangani tanpa mem-parsing pesan
+const (
+	detectionErrUnauthorized     = "UNAUTHORIZED"
+	detectionErrInvalidBody      = "INVALID_BODY"
+	detectionErrInvalidGroupID   = "INVALID_GROUP_ID"
+	detectionErrGroupNotFound    = "GROUP_NOT_FOUND"
+	detectionErrNotMember        = "NOT_GROUP_MEMBER"
+	detectionErrSessionInactive  = "SESSION_NOT_ACTIVE"
+	detectionErrSessionPaused    = "SESSION_PAUSED"
+	detectionErrConsentRequired  = "CONSENT_REQUIRED"
+	detectionErrMissingEmotions  = "MISSING_EMOTIONS"
+	detectionErrUnknownLabel     = "UNKNOWN_EMOTION_LABEL"
+	detectionErrOutOfRange       = "PROBABILITY_OUT_OF_RANGE"
+	detectionErrInvalidSum       = "PROBABILITY_SUM_INVALID"
+	detectionErrInvalidTimestamp = "INVALID_TIMESTAMP"
+	detectionErrInvalidSeq       = "INVALID_SEQ"
+	detectionErrInvalidSampleID  = "INVALID_SAMPLE_ID"
+)
+
+// maxSampleIDLength adalah panjang maksimum sampleId buatan client
+const maxSampleIDLength = 100
+
+// detectionError adalah error validasi deteksi yang dikirim sebagai respons 4xx terstruktur
+type detectionError struct {
+	Status  int    `json:"-"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+	Field   string `json:"field,omitempty"`
+	// Details berisi data tambahan untuk client, mis. versi kebijakan persetujuan
+	Details fiber.Map `json:"-"`
+}
+
+func newDetectionError(status int, code, message, field string) *detectionError {
+	return &detectionError{Status: status, Code: code, Message: message, Field: field}
+}
+
+// response mengubah error menjadi body JSON dengan format respons repo
+func (e *detectionError) response() fiber.Map {
+	body := fiber.Map{"success": false, "code": e.Code, "message": e.Message}
+	if e.Field != "" {
+		body["field"] = e.Field
+	}
+	for k, v := range e.Details {
+		body[k] = v
+	}
+	return body
+}
+
+// emotionSumTolerance adalah selisih maksimal jumlah probabilitas kategori dari 1 (DETECTION_SUM_TOLERANCE, persen)
+func emotionSumTolerance() float64 {
+	return float64(config.GetEnvInt("DETECTION_SUM_TOLERANCE", 5)) / 100
+}
+
+// validateEmotionPayload memvalidasi nilai emosi dari payload terhadap taksonomi aktif.
+// Kategori harus 0..1 dan totalnya ~1, dimensi (mis. valence) harus di dalam rentangnya.
+// Jika emotions kosong, label lama (field emotion) dipakai dengan probabilitas 1.
+func validateEmotionPayload(emotions map[string]float64, label string, field string) (models.Emotion, *detectionError) {
+	tax := taxonomy.Current()
+	if len(emotions) == 0 {
+		if label == "" {
+			return nil, newDetectionError(422, detectionErrMissingEmotions, "emotions or emotion is required", field)
+		}
+		if !tax.IsCategory(label) {
+			return nil, newDetectionError(422, detectionErrUnknownLabel, fmt.Sprintf("Unknown emotion label %q", label), field+"emotion")
+		}
+		return models.Emotion{label: 1}, nil
+	}
+	sum, categories := 0.0, 0
+	for name, v := range emotions {
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			return nil, newDetectionError(422, detectionErrOutOfRange, "Value must be a number", field+"emotions."+name)
+		}
+		if tax.IsCategory(name) {
+			if v < 0 || v > 1 {
+				return nil, newDetectionError(422, detectionErrOutOfRange, "Probability must be between 0 and 1", field+"emotions."+name)
+			}
+			sum += v
+			categories++
+			continue
+		}
+		r, ok := tax.Dimension(name)
+		if !ok {
+			return nil, newDetectionError(422, detectionErrUnknownLabel, fmt.Sprintf("Unknown emotion label %q", name), field+"emotions."+name)
+		}
+		if v < r.Min || v > r.Max {
+			return nil, newDetectionError(422, detectionErrOutOfRange, fmt.Sprintf("Value must be between %g and %g", r.Min, r.Max), field+"emotions."+name)
+		}
+	}
+	// Payload yang hanya berisi dimensi tidak dicek totalnya
+	if categories > 0 && math.Abs(sum-1) > emotionSumTolerance() {
+		return nil, newDetectionError(422, detectionErrInvalidSum, fmt.Sprintf("Probabilities must sum to 1 (got %.3f)", sum), field+"emotions")
+	}
+	return models.Emotion(emotions), nil
+}
+
+// validateSignals memvalidasi sinyal perhatian opsional. Sinyal hanya diterima jika user
+// sudah menyetujui cakupan engagement_signals untuk sesi ini.
+func validateSignals(target detectionTarget, s *models.DetectionSignals, field string) *detectionError {
+	if s == nil {
+		return nil
+	}
+	field += "signals."
+	if !target.SignalsAllowed {
+		derr := newDetectionError(403, detectionErrConsentRequired, "Consent required for engagement signals", field[:len(field)-1])
+		derr.Details = fiber.Map{"policyVersion": consentPolicyVersion(), "scope": models.ConsentScopeEngagement}
+		return derr
+	}
+	if s.FaceCount != nil && *s.FaceCount < 0 {
+		return newDetectionError(422, detectionErrOutOfRange, "faceCount must not be negative", field+"faceCount")
+	}
+	if s.EyesClosedRatio != nil && (math.IsNaN(*s.EyesClosedRatio) || *s.EyesClosedRatio < 0 || *s.EyesClosedRatio > 1) {
+		return newDetectionError(422, detectionErrOutOfRange, "eyesClosedRatio must be between 0 and 1", field+"eyesClosedRatio")
+	}
+	angles := map[string]float64{}
+	if s.HeadPose != nil {
+		angles["headPose.yaw"] = s.HeadPose.Yaw
+		angles["headPose.pitch"] = s.HeadPose.Pitch
+		angles["headPose.roll"] = s.HeadPose.Roll
+	}
+	if s.Gaze != nil {
+		angles["gaze.yaw"] = s.Gaze.Yaw
+		angles["gaze.pitch"] = s.Gaze.Pitch
+	}
+	for name, v := range angles {
+		if math.IsNaN(v) || v < -180 || v > 180 {
+			return newDetectionError(422, detectionErrOutOfRange, "Angle must be between -180 and 180 degrees", field+name)
+		}
+	}
+	return nil
+}
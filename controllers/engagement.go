@@ -0,0 +1,200 @@
+package controllers
+
+import (
+	"context"
+	"math"
+	"time"
+
+	"sitor-backend/config"
+	"sitor-backend/models"
+
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// Sudut (derajat) kepala/pandangan yang masih dianggap memperhatikan layar,
+// di atas batas penuh skor turun linear sampai 0 di batas nol
+const (
+	attentionAngleFull = 15.0
+	attentionAngleZero = 60.0
+)
+
+// engagementScore menghitung skor perhatian 0..1 dari sinyal satu sampel.
+// Wajah tidak terdeteksi bernilai 0; arah kepala/pandangan yang menjauh dan mata tertutup menurunkan skor.
+// Mengembalikan nil jika tidak ada sinyal.
+func engagementScore(s *models.DetectionSignals) *float64 {
+	if s == nil {
+		return nil
+	}
+	score := 1.0
+	if (s.FacePresent != nil && !*s.FacePresent) || (s.FaceCount != nil && *s.FaceCount == 0) {
+		score = 0
+		return &score
+	}
+	if s.HeadPose != nil {
+		score *= angleFactor(math.Max(math.Abs(s.HeadPose.Yaw), math.Abs(s.HeadPose.Pitch)))
+	}
+	if s.Gaze != nil {
+		score *= angleFactor(math.Max(math.Abs(s.Gaze.Yaw), math.Abs(s.Gaze.Pitch)))
+	}
+	if s.EyesClosedRatio != nil {
+		score *= 1 - *s.EyesClosedRatio
+	}
+	return &score
+}
+
+func angleFactor(angle float64) float64 {
+	switch {
+	case angle <= attentionAngleFull:
+		return 1
+	case angle >= attentionAngleZero:
+		return 0
+	default:
+		return 1 - (angle-attentionAngleFull)/(attentionAngleZero-attentionAngleFull)
+	}
+}
+
+// memberEngagement adalah ringkasan engagement satu anggota
+type memberEngagement struct {
+	UserID  primitive.ObjectID `bson:"_id" json:"userId"`
+	Average float64            `bson:"average" json:"average"`
+	Min     float64            `bson:"min" json:"min"`
+	Samples int                `bson:"samples" json:"samples"`
+	Name    string             `bson:"-" json:"name"`
+}
+
+// aggregateEngagement menghitung rata-rata engagement per anggota dari sampel time series
+func aggregateEngagement(ctx context.Context, match bson.M) ([]memberEngagement, error) {
+	match["engagement"] = bson.M{"$exists": true}
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: match}},
+		{{Key: "$group", Value: bson.M{
+			"_id":     "$meta.userId",
+			"average": bson.M{"$avg": "$engagement"},
+			"min":     bson.M{"$min": "$engagement"},
+			"samples": bson.M{"$sum": 1},
+		}}},
+		{{Key: "$sort", Value: bson.M{"average": 1}}},
+	}
+	cursor, err := detectionSampleCol.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	members := []memberEngagement{}
+	if err := cursor.All(ctx, &members); err != nil {
+		return nil, err
+	}
+	if len(members) == 0 {
+		return members, nil
+	}
+	ids := []primitive.ObjectID{}
+	for _, m := range members {
+		ids = append(ids, m.UserID)
+	}
+	cursor, err = userCol.Find(ctx, bson.M{"_id": bson.M{"$in": ids}})
+	if err != nil {
+		return nil, err
+	}
+	var users []models.User
+	if err := cursor.All(ctx, &users); err != nil {
+		return nil, err
+	}
+	names := map[primitive.ObjectID]string{}
+	for _, u := range users {
+		names[u.ID] = u.Name
+	}
+	for i := range members {
+		members[i].Name = names[members[i].UserID]
+	}
+	return members, nil
+}
+
+// engagementResponse menyusun respons engagement per anggota beserta rata-rata kelas
+func engagementResponse(members []memberEngagement) fiber.Map {
+	var classAverage interface{}
+	if len(members) > 0 {
+		sum := 0.0
+		for _, m := range members {
+			sum += m.Average
+		}
+		classAverage = sum / float64(len(members))
+	}
+	return fiber.Map{"success": true, "classAverage": classAverage, "members": members}
+}
+
+// GET /api/groups/:groupId/engagement?window=5m
+// Engagement terkini per anggota di sesi aktif, dari sampel dalam jendela waktu terakhir
+func GetGroupEngagement(c *fiber.Ctx) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	group, status, msg := loadManagedGroup(ctx, c, "groupId")
+	if status != 0 {
+		return c.Status(status).JSON(fiber.Map{"success": false, "message": msg})
+	}
+	session, ok := findActiveSession(ctx, group.ID)
+	if !ok {
+		return c.Status(409).JSON(fiber.Map{"success": false, "message": "Session is not active"})
+	}
+	window := config.GetEnvDuration("ENGAGEMENT_WINDOW", 5*time.Minute)
+	if q := c.Query("window"); q != "" {
+		d, err := time.ParseDuration(q)
+		if err != nil || d <= 0 {
+			return c.Status(400).JSON(fiber.Map{"success": false, "message": "Invalid window, use a duration like 5m"})
+		}
+		window = d
+	}
+	members, err := aggregateEngagement(ctx, bson.M{
+		"meta.sessionId": session.ID,
+		"timestamp":      bson.M{"$gte": time.Now().Add(-window)},
+	})
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"success": false, "message": "Failed to compute engagement"})
+	}
+	resp := engagementResponse(members)
+	resp["sessionId"] = session.ID
+	resp["window"] = window.String()
+	return c.JSON(resp)
+}
+
+// GET /api/sessions/:id/engagement
+// Engagement per anggota selama satu sesi. Anggota biasa hanya melihat miliknya sendiri.
+func GetSessionEngagement(c *fiber.Ctx) error {
+	userId := c.Locals("userId")
+	if userId == nil {
+		return c.Status(401).JSON(fiber.Map{"success": false, "message": "Unauthorized"})
+	}
+	uid, err := primitive.ObjectIDFromHex(userId.(string))
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"success": false, "message": "Invalid userId"})
+	}
+	sid, err := primitive.ObjectIDFromHex(c.Params("id"))
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"success": false, "message": "Invalid sessionId"})
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+	var session models.Session
+	if err := sessionCol.FindOne(ctx, bson.M{"_id": sid}).Decode(&session); err != nil {
+		return c.Status(404).JSON(fiber.Map{"success": false, "message": "Session not found"})
+	}
+	group, err := findGroup(ctx, session.GroupID)
+	if err != nil {
+		return c.Status(404).JSON(fiber.Map{"success": false, "message": "Group not found"})
+	}
+	match := bson.M{"meta.sessionId": sid}
+	if !canManageGroup(ctx, group, userId.(string)) {
+		if !groupHasMember(group, uid) {
+			return c.Status(403).JSON(fiber.Map{"success": false, "message": "Not a member of this group"})
+		}
+		match["meta.userId"] = uid
+	}
+	members, err := aggregateEngagement(ctx, match)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"success": false, "message": "Failed to compute engagement"})
+	}
+	resp := engagementResponse(members)
+	resp["sessionId"] = sid
+	return c.JSON(resp)
+}
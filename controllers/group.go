@@ -12,15 +12,55 @@ import (
 	"github.com/gofiber/fiber/v2"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
 )
 
 var groupCol = config.GetDB().Collection("groups")
 
+// findGroup mengambil grup berdasarkan ID, grup yang sudah dihapus (soft delete) diabaikan
+func findGroup(ctx context.Context, id primitive.ObjectID) (models.Group, error) {
+	var group models.Group
+	err := groupCol.FindOne(ctx, bson.M{"_id": id, "deletedAt": nil}).Decode(&group)
+	return group, err
+}
+
+// groupView membentuk data grup yang aman dikirim ke client (tanpa securityCode).
+// Daftar anggota hanya disertakan jika withMembers true.
+func groupView(g models.Group, withMembers bool) map[string]interface{} {
+	// Fallback jika LeaderID/Members nil
+	leaderIdStr := ""
+	if g.LeaderID != primitive.NilObjectID {
+		leaderIdStr = g.LeaderID.Hex()
+	}
+	item := map[string]interface{}{
+		"id":          g.ID.Hex(),
+		"name":        g.Name,
+		"description": g.Description,
+		"leaderId":    leaderIdStr,
+		"createdAt":   g.CreatedAt,
+	}
+	if withMembers {
+		members := make([]string, 0)
+		for i, m := range g.Members {
+			if m != primitive.NilObjectID {
+				members = append(members, m.Hex())
+			} else {
+				fmt.Println("[WARN] NilObjectID in members at index", i)
+			}
+		}
+		item["members"] = members
+	}
+	if !g.OrganizationID.IsZero() {
+		item["organizationId"] = g.OrganizationID.Hex()
+	}
+	return item
+}
+
 // GET /api/groups
 func GetGroups(c *fiber.Ctx) error {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
-	cursor, err := groupCol.Find(ctx, bson.M{})
+	cursor, err := groupCol.Find(ctx, bson.M{"deletedAt": nil})
 	if err != nil {
 		fmt.Println("[ERROR] groupCol.Find:", err)
 		return c.Status(500).JSON(fiber.Map{"success": false, "message": "Failed to fetch groups", "error": err.Error()})
@@ -32,29 +72,7 @@ func GetGroups(c *fiber.Ctx) error {
 	}
 	var groupsWithStringMembers []map[string]interface{}
 	for _, g := range groups {
-		// Fallback jika LeaderID/Members nil
-		leaderIdStr := ""
-		if g.LeaderID != primitive.NilObjectID {
-			leaderIdStr = g.LeaderID.Hex()
-		}
-		members := make([]string, 0)
-		if g.Members != nil {
-			for i, m := range g.Members {
-				if m != primitive.NilObjectID {
-					members = append(members, m.Hex())
-				} else {
-					fmt.Println("[WARN] NilObjectID in members at index", i)
-				}
-			}
-		}
-		groupsWithStringMembers = append(groupsWithStringMembers, map[string]interface{}{
-			"id":          g.ID.Hex(),
-			"name":        g.Name,
-			"description": g.Description,
-			"leaderId":    leaderIdStr,
-			"members":     members,
-			"createdAt":   g.CreatedAt,
-		})
+		groupsWithStringMembers = append(groupsWithStringMembers, groupView(g, true))
 	}
 	return c.JSON(fiber.Map{"success": true, "groups": groupsWithStringMembers})
 }
@@ -68,6 +86,8 @@ func CreateGroup(c *fiber.Ctx) error {
 		Name         string `json:"name"`
 		Description  string `json:"description"`
 		SecurityCode string `json:"securityCode"`
+		// Opsional: buat grup langsung di bawah organisasi (harus admin organisasi)
+		OrganizationId string `json:"organizationId"`
 	}
 	var body reqBody
 	if err := c.BodyParser(&body); err != nil {
@@ -78,17 +98,36 @@ func CreateGroup(c *fiber.Ctx) error {
 	if err != nil {
 		return c.Status(400).JSON(fiber.Map{"success": false, "message": "Invalid userId"})
 	}
+	var orgObjId primitive.ObjectID
+	if body.OrganizationId != "" {
+		orgObjId, err = primitive.ObjectIDFromHex(body.OrganizationId)
+		if err != nil {
+			return c.Status(400).JSON(fiber.Map{"success": false, "message": "Invalid organizationId"})
+		}
+		count, _ := organizationCol.CountDocuments(context.TODO(), bson.M{"_id": orgObjId, "admins": leaderObjId})
+		if count == 0 {
+			return c.Status(403).JSON(fiber.Map{"success": false, "message": "Only organization admins can create groups in this organization"})
+		}
+	}
 	group := models.Group{
-		ID:            primitive.NewObjectID(),
-		Name:          body.Name,
-		Description:   body.Description,
-		SecurityCode:  hash,
-		LeaderID:      leaderObjId,
-		Members:       []primitive.ObjectID{leaderObjId},
-		CreatedAt:     time.Now(),
-		SessionActive: true, // Pastikan sesi aktif saat grup dibuat
+		ID:             primitive.NewObjectID(),
+		Name:           body.Name,
+		Description:    body.Description,
+		SecurityCode:   hash,
+		LeaderID:       leaderObjId,
+		Members:        []primitive.ObjectID{leaderObjId},
+		CreatedAt:      time.Now(),
+		OrganizationID: orgObjId,
 	}
-	_, err = groupCol.InsertOne(context.TODO(), group)
+	// Grup baru belum punya sesi; leader membuka ruang tunggu atau memulai sesi secara eksplisit.
+	// Simpan grup dan catat di joinedGroups leader dalam satu transaksi
+	err = withTransaction(context.TODO(), func(sc mongo.SessionContext) error {
+		if _, err := groupCol.InsertOne(sc, group); err != nil {
+			return err
+		}
+		_, err := userCol.UpdateOne(sc, bson.M{"_id": leaderObjId}, bson.M{"$addToSet": bson.M{"joinedGroups": group.ID}})
+		return err
+	})
 	if err != nil {
 		return c.Status(500).JSON(fiber.Map{"success": false, "message": "Failed to create group"})
 	}
@@ -109,8 +148,7 @@ func JoinGroup(c *fiber.Ctx) error {
 	if err != nil {
 		return c.Status(400).JSON(fiber.Map{"success": false, "message": "Invalid groupId"})
 	}
-	var group models.Group
-	err = groupCol.FindOne(context.TODO(), bson.M{"_id": objGroupId}).Decode(&group)
+	group, err := findGroup(context.TODO(), objGroupId)
 	if err != nil {
 		return c.Status(404).JSON(fiber.Map{"success": false, "message": "Group not found"})
 	}
@@ -126,7 +164,7 @@ func JoinGroup(c *fiber.Ctx) error {
 			return c.Status(400).JSON(fiber.Map{"success": false, "message": "Already joined"})
 		}
 	}
-	_, err = groupCol.UpdateOne(context.TODO(), bson.M{"_id": objGroupId}, bson.M{"$push": bson.M{"members": userObjId}})
+	err = addGroupMember(context.TODO(), objGroupId, userObjId)
 	if err != nil {
 		return c.Status(500).JSON(fiber.Map{"success": false, "message": "Failed to join group"})
 	}
@@ -139,8 +177,7 @@ func ListGroupMembers(c *fiber.Ctx) error {
 	if err != nil {
 		return c.Status(400).JSON(fiber.Map{"success": false, "message": "Invalid groupId"})
 	}
-	var group models.Group
-	err = groupCol.FindOne(context.TODO(), bson.M{"_id": objId}).Decode(&group)
+	group, err := findGroup(context.TODO(), objId)
 	if err != nil {
 		return c.Status(404).JSON(fiber.Map{"success": false, "message": "Group not found"})
 	}
@@ -158,19 +195,38 @@ func DeleteGroup(c *fiber.Ctx) error {
 	if err != nil {
 		return c.Status(400).JSON(fiber.Map{"success": false, "message": "Invalid groupId"})
 	}
-	var group models.Group
-	err = groupCol.FindOne(context.TODO(), bson.M{"_id": objGroupId}).Decode(&group)
+	group, err := findGroup(context.TODO(), objGroupId)
 	if err != nil {
 		return c.Status(404).JSON(fiber.Map{"success": false, "message": "Group not found"})
 	}
-	if group.LeaderID.Hex() != userId.(string) {
-		return c.Status(403).JSON(fiber.Map{"success": false, "message": "Only the group leader can delete the group"})
+	if !canManageGroup(context.TODO(), group, userId.(string)) {
+		return c.Status(403).JSON(fiber.Map{"success": false, "message": "Only the group leader or an organization admin can delete the group"})
 	}
-	_, err = groupCol.DeleteOne(context.TODO(), bson.M{"_id": objGroupId})
+	// Opsional: ekspor riwayat grup sebelum dihapus (?export=true)
+	var export fiber.Map
+	if c.QueryBool("export") {
+		export, err = buildGroupExport(context.TODO(), group)
+		if err != nil {
+			return c.Status(500).JSON(fiber.Map{"success": false, "message": "Failed to export group", "error": err.Error()})
+		}
+	}
+	// Sesi yang masih berjalan diakhiri dulu agar PIN dan status kamera tidak tetap berlaku
+	if group.SessionActive {
+		if _, err := endGroupSession(context.TODO(), objGroupId, models.SessionEndGroupDeleted); err != nil && err != errSessionNotActive {
+			return c.Status(500).JSON(fiber.Map{"success": false, "message": "Failed to end active session", "error": err.Error()})
+		}
+	}
+	// Soft delete: data terkait baru dihapus permanen oleh worker purge setelah masa restore habis
+	now := time.Now()
+	_, err = groupCol.UpdateOne(context.TODO(), bson.M{"_id": objGroupId}, bson.M{"$set": bson.M{"deletedAt": now}})
 	if err != nil {
 		return c.Status(500).JSON(fiber.Map{"success": false, "message": "Failed to delete group"})
 	}
-	return c.JSON(fiber.Map{"success": true})
+	resp := fiber.Map{"success": true, "restoreUntil": now.Add(groupRestoreWindow())}
+	if export != nil {
+		resp["export"] = export
+	}
+	return c.JSON(resp)
 }
 
 // POST /api/groups/:id/leave
@@ -184,8 +240,7 @@ func LeaveGroup(c *fiber.Ctx) error {
 	if err != nil {
 		return c.Status(400).JSON(fiber.Map{"success": false, "message": "Invalid groupId"})
 	}
-	var group models.Group
-	err = groupCol.FindOne(context.TODO(), bson.M{"_id": objGroupId}).Decode(&group)
+	group, err := findGroup(context.TODO(), objGroupId)
 	if err != nil {
 		return c.Status(404).JSON(fiber.Map{"success": false, "message": "Group not found"})
 	}
@@ -196,7 +251,7 @@ func LeaveGroup(c *fiber.Ctx) error {
 	if err != nil {
 		return c.Status(400).JSON(fiber.Map{"success": false, "message": "Invalid userId"})
 	}
-	_, err = groupCol.UpdateOne(context.TODO(), bson.M{"_id": objGroupId}, bson.M{"$pull": bson.M{"members": userObjId}})
+	err = removeGroupMember(context.TODO(), objGroupId, userObjId)
 	if err != nil {
 		return c.Status(500).JSON(fiber.Map{"success": false, "message": "Failed to leave group"})
 	}
@@ -0,0 +1,178 @@
+package controllers
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"sitor-backend/config"
+	"sitor-backend/models"
+
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// Koleksi yang menyimpan data milik grup (field groupId), ikut dihapus saat grup di-purge
+var groupScopedCollections = []string{"detections", "detection_history", "camera_status", "breakout_rooms", "group_invites", "session_schedules", "sessions", "notifications", "attendance_events", "session_markers", "consents", "lobby_entries", "alert_rules", "alerts", "webhooks", "webhook_deliveries"}
+
+// groupRestoreWindow adalah lama grup yang dihapus masih bisa dipulihkan (GROUP_RESTORE_WINDOW)
+func groupRestoreWindow() time.Duration {
+	return config.GetEnvDuration("GROUP_RESTORE_WINDOW", 7*24*time.Hour)
+}
+
+// findDeletedGroup mengambil grup yang sudah di-soft delete
+func findDeletedGroup(ctx context.Context, id primitive.ObjectID) (models.Group, error) {
+	var group models.Group
+	err := groupCol.FindOne(ctx, bson.M{"_id": id, "deletedAt": bson.M{"$ne": nil}}).Decode(&group)
+	return group, err
+}
+
+// POST /api/groups/:id/restore
+func RestoreGroup(c *fiber.Ctx) error {
+	userId := c.Locals("userId")
+	if userId == nil {
+		return c.Status(401).JSON(fiber.Map{"success": false, "message": "Unauthorized"})
+	}
+	objGroupId, err := primitive.ObjectIDFromHex(c.Params("id"))
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"success": false, "message": "Invalid groupId"})
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	group, err := findDeletedGroup(ctx, objGroupId)
+	if err != nil {
+		return c.Status(404).JSON(fiber.Map{"success": false, "message": "Deleted group not found"})
+	}
+	if !canManageGroup(ctx, group, userId.(string)) {
+		return c.Status(403).JSON(fiber.Map{"success": false, "message": "Only the group leader or an organization admin can restore the group"})
+	}
+	if time.Since(*group.DeletedAt) > groupRestoreWindow() {
+		return c.Status(410).JSON(fiber.Map{"success": false, "message": "Restore window has expired"})
+	}
+	_, err = groupCol.UpdateOne(ctx, bson.M{"_id": objGroupId}, bson.M{"$unset": bson.M{"deletedAt": ""}})
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"success": false, "message": "Failed to restore group"})
+	}
+	return c.JSON(fiber.Map{"success": true})
+}
+
+// GET /api/groups/:id/export
+// Bisa dipakai juga untuk grup yang sudah dihapus selama masa restore belum habis
+func ExportGroup(c *fiber.Ctx) error {
+	userId := c.Locals("userId")
+	if userId == nil {
+		return c.Status(401).JSON(fiber.Map{"success": false, "message": "Unauthorized"})
+	}
+	objGroupId, err := primitive.ObjectIDFromHex(c.Params("id"))
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"success": false, "message": "Invalid groupId"})
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	var group models.Group
+	err = groupCol.FindOne(ctx, bson.M{"_id": objGroupId}).Decode(&group)
+	if err != nil {
+		return c.Status(404).JSON(fiber.Map{"success": false, "message": "Group not found"})
+	}
+	if !canManageGroup(ctx, group, userId.(string)) {
+		return c.Status(403).JSON(fiber.Map{"success": false, "message": "Only the group leader or an organization admin can export the group"})
+	}
+	export, err := buildGroupExport(ctx, group)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"success": false, "message": "Failed to export group", "error": err.Error()})
+	}
+	c.Set(fiber.HeaderContentDisposition, `attachment; filename="group-`+group.ID.Hex()+`.json"`)
+	return c.JSON(fiber.Map{"success": true, "export": export})
+}
+
+// buildGroupExport mengumpulkan data grup beserta anggota, deteksi aktif, dan riwayat sesi
+func buildGroupExport(ctx context.Context, group models.Group) (fiber.Map, error) {
+	db := config.GetDB()
+	members := []fiber.Map{}
+	if len(group.Members) > 0 {
+		cursor, err := userCol.Find(ctx, bson.M{"_id": bson.M{"$in": group.Members}})
+		if err != nil {
+			return nil, err
+		}
+		var users []models.User
+		if err := cursor.All(ctx, &users); err != nil {
+			return nil, err
+		}
+		for _, u := range users {
+			members = append(members, fiber.Map{"id": u.ID.Hex(), "name": u.Name, "email": u.Email})
+		}
+	}
+	export := fiber.Map{
+		"group": fiber.Map{
+			"id":          group.ID.Hex(),
+			"name":        group.Name,
+			"description": group.Description,
+			"leaderId":    group.LeaderID.Hex(),
+			"createdAt":   group.CreatedAt,
+		},
+		"members":    members,
+		"exportedAt": time.Now(),
+	}
+	for _, name := range []string{"detections", "detection_history"} {
+		cursor, err := db.Collection(name).Find(ctx, bson.M{"groupId": group.ID})
+		if err != nil {
+			return nil, err
+		}
+		docs := []bson.M{}
+		if err := cursor.All(ctx, &docs); err != nil {
+			return nil, err
+		}
+		export[name] = docs
+	}
+	return export, nil
+}
+
+// StartGroupPurgeWorker menjalankan pembersihan permanen grup yang masa restore-nya sudah habis
+func StartGroupPurgeWorker() {
+	runEvery("PURGE", config.GetEnvDuration("GROUP_PURGE_INTERVAL", time.Hour), purgeExpiredGroups)
+}
+
+func purgeExpiredGroups() {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	cutoff := time.Now().Add(-groupRestoreWindow())
+	cursor, err := groupCol.Find(ctx, bson.M{"deletedAt": bson.M{"$ne": nil, "$lte": cutoff}})
+	if err != nil {
+		log.Printf("[PURGE] failed to find expired groups: %v", err)
+		return
+	}
+	var groups []models.Group
+	if err := cursor.All(ctx, &groups); err != nil {
+		log.Printf("[PURGE] failed to decode expired groups: %v", err)
+		return
+	}
+	for _, g := range groups {
+		if err := purgeGroup(ctx, g.ID); err != nil {
+			log.Printf("[PURGE] failed to purge group %s: %v", g.ID.Hex(), err)
+			continue
+		}
+		log.Printf("[PURGE] group %s purged", g.ID.Hex())
+	}
+}
+
+// purgeGroup menghapus grup dan semua data terkait dalam satu transaksi
+func purgeGroup(ctx context.Context, groupId primitive.ObjectID) error {
+	db := config.GetDB()
+	if err := deleteGroupSamples(ctx, groupId); err != nil {
+		return err
+	}
+	return withTransaction(ctx, func(sc mongo.SessionContext) error {
+		for _, name := range groupScopedCollections {
+			if _, err := db.Collection(name).DeleteMany(sc, bson.M{"groupId": groupId}); err != nil {
+				return err
+			}
+		}
+		if _, err := userCol.UpdateMany(sc, bson.M{"joinedGroups": groupId}, bson.M{"$pull": bson.M{"joinedGroups": groupId}}); err != nil {
+			return err
+		}
+		_, err := groupCol.DeleteOne(sc, bson.M{"_id": groupId, "deletedAt": bson.M{"$ne": nil}})
+		return err
+	})
+}
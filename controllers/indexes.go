@@ -0,0 +1,34 @@
+package controllers
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"sitor-backend/middleware"
+)
+
+// indexInitializers berisi fungsi pembuat index (dan koleksi khusus) MongoDB yang dibutuhkan controller
+var indexInitializers = map[string]func(ctx context.Context) error{
+	"detection_history":    ensureArchiveIndexes,
+	"consents":             ensureConsentIndexes,
+	"lobby_entries":        ensureLobbyIndexes,
+	"sessions":             ensureSessionPINIndexes,
+	"detection_samples":    ensureDetectionSamplesCollection,
+	"alerts":               ensureAlertIndexes,
+	"webhook_deliveries":   ensureWebhookIndexes,
+	"idempotency_keys":     middleware.EnsureIdempotencyIndexes,
+	"detection_sample_ids": ensureSampleIDIndexes,
+}
+
+// EnsureIndexes membuat index yang dibutuhkan, dipanggil sekali saat startup.
+// Kegagalan hanya dicatat agar server tetap bisa berjalan.
+func EnsureIndexes() {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	for name, fn := range indexInitializers {
+		if err := fn(ctx); err != nil {
+			log.Printf("[INDEX] failed to create indexes for %s: %v", name, err)
+		}
+	}
+}
@@ -0,0 +1,210 @@
+package controllers
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"sitor-backend/config"
+	"sitor-backend/models"
+
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var lobbyCol = config.GetDB().Collection("lobby_entries")
+
+// findLobbyEntries mengambil daftar tunggu grup, urut dari yang check-in paling awal
+func findLobbyEntries(ctx context.Context, groupId primitive.ObjectID) ([]models.LobbyEntry, error) {
+	opts := options.Find().SetSort(bson.M{"checkedInAt": 1})
+	cursor, err := lobbyCol.Find(ctx, bson.M{"groupId": groupId}, opts)
+	if err != nil {
+		return nil, err
+	}
+	entries := []models.LobbyEntry{}
+	if err := cursor.All(ctx, &entries); err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+// POST /api/groups/:groupId/lobby/open
+func OpenLobby(c *fiber.Ctx) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	group, status, msg := loadManagedGroup(ctx, c, "groupId")
+	if status != 0 {
+		return c.Status(status).JSON(fiber.Map{"success": false, "message": msg})
+	}
+	if group.SessionActive {
+		return c.Status(409).JSON(fiber.Map{"success": false, "message": "Session already started"})
+	}
+	if _, err := groupCol.UpdateOne(ctx, bson.M{"_id": group.ID}, bson.M{"$set": bson.M{"lobbyOpen": true}}); err != nil {
+		return c.Status(500).JSON(fiber.Map{"success": false, "message": "Failed to open lobby"})
+	}
+	return c.JSON(fiber.Map{"success": true, "lobbyOpen": true})
+}
+
+// POST /api/groups/:groupId/lobby/close
+// Menutup ruang tunggu tanpa memulai sesi, semua anggota yang menunggu dikeluarkan
+func CloseLobby(c *fiber.Ctx) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	group, status, msg := loadManagedGroup(ctx, c, "groupId")
+	if status != 0 {
+		return c.Status(status).JSON(fiber.Map{"success": false, "message": msg})
+	}
+	err := withTransaction(ctx, func(sc mongo.SessionContext) error {
+		if _, err := groupCol.UpdateOne(sc, bson.M{"_id": group.ID}, bson.M{"$unset": bson.M{"lobbyOpen": ""}}); err != nil {
+			return err
+		}
+		_, err := lobbyCol.DeleteMany(sc, bson.M{"groupId": group.ID})
+		return err
+	})
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"success": false, "message": "Failed to close lobby"})
+	}
+	return c.JSON(fiber.Map{"success": true, "lobbyOpen": false})
+}
+
+// GET /api/groups/:groupId/lobby
+// Daftar tunggu untuk leader
+func GetLobby(c *fiber.Ctx) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	group, status, msg := loadManagedGroup(ctx, c, "groupId")
+	if status != 0 {
+		return c.Status(status).JSON(fiber.Map{"success": false, "message": msg})
+	}
+	entries, err := findLobbyEntries(ctx, group.ID)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"success": false, "message": "Failed to fetch lobby"})
+	}
+	return c.JSON(fiber.Map{
+		"success":       true,
+		"lobbyOpen":     group.LobbyOpen,
+		"sessionActive": group.SessionActive,
+		"waiting":       entries,
+	})
+}
+
+// POST /api/groups/:groupId/lobby/check-in
+func CheckInLobby(c *fiber.Ctx) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	group, uid, status, msg := loadGroupMember(ctx, c)
+	if status != 0 {
+		return c.Status(status).JSON(fiber.Map{"success": false, "message": msg})
+	}
+	if group.SessionActive {
+		return c.Status(409).JSON(fiber.Map{"success": false, "message": "Session already started", "sessionId": group.ActiveSessionID})
+	}
+	if !group.LobbyOpen {
+		return c.Status(409).JSON(fiber.Map{"success": false, "message": "Lobby is not open"})
+	}
+	var user models.User
+	if err := userCol.FindOne(ctx, bson.M{"_id": uid}).Decode(&user); err != nil {
+		return c.Status(404).JSON(fiber.Map{"success": false, "message": "User not found"})
+	}
+	var entry models.LobbyEntry
+	opts := options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)
+	err := lobbyCol.FindOneAndUpdate(ctx,
+		bson.M{"groupId": group.ID, "userId": uid},
+		bson.M{
+			"$set":         bson.M{"userName": user.Name},
+			"$setOnInsert": bson.M{"checkedInAt": time.Now()},
+		}, opts).Decode(&entry)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"success": false, "message": "Failed to check in"})
+	}
+	return c.JSON(fiber.Map{"success": true, "entry": entry})
+}
+
+// DELETE /api/groups/:groupId/lobby/check-in
+func LeaveLobby(c *fiber.Ctx) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	group, uid, status, msg := loadGroupMember(ctx, c)
+	if status != 0 {
+		return c.Status(status).JSON(fiber.Map{"success": false, "message": msg})
+	}
+	res, err := lobbyCol.DeleteOne(ctx, bson.M{"groupId": group.ID, "userId": uid})
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"success": false, "message": "Failed to leave lobby"})
+	}
+	if res.DeletedCount == 0 {
+		return c.Status(404).JSON(fiber.Map{"success": false, "message": "Not checked in"})
+	}
+	return c.JSON(fiber.Map{"success": true})
+}
+
+// POST /api/groups/:groupId/lobby/camera-test
+// Body: {"cameraOk": true, "error": ""}
+// Client melaporkan hasil tes kamera sehingga leader tahu siapa yang siap
+func ReportLobbyCameraTest(c *fiber.Ctx) error {
+	var body struct {
+		CameraOK *bool  `json:"cameraOk"`
+		Error    string `json:"error"`
+	}
+	if err := c.BodyParser(&body); err != nil || body.CameraOK == nil {
+		return c.Status(400).JSON(fiber.Map{"success": false, "message": "cameraOk is required"})
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	group, uid, status, msg := loadGroupMember(ctx, c)
+	if status != 0 {
+		return c.Status(status).JSON(fiber.Map{"success": false, "message": msg})
+	}
+	set := bson.M{"cameraOk": *body.CameraOK, "cameraTestedAt": time.Now()}
+	update := bson.M{"$set": set}
+	if body.Error != "" && !*body.CameraOK {
+		set["cameraError"] = body.Error
+	} else {
+		update["$unset"] = bson.M{"cameraError": ""}
+	}
+	var entry models.LobbyEntry
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+	err := lobbyCol.FindOneAndUpdate(ctx, bson.M{"groupId": group.ID, "userId": uid}, update, opts).Decode(&entry)
+	if err == mongo.ErrNoDocuments {
+		return c.Status(404).JSON(fiber.Map{"success": false, "message": "Check in to the lobby first"})
+	}
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"success": false, "message": "Failed to save camera test"})
+	}
+	return c.JSON(fiber.Map{"success": true, "entry": entry})
+}
+
+// admitLobby memindahkan semua anggota di ruang tunggu ke sesi yang baru dimulai:
+// dicatat hadir (join) dan sebagai peserta, lalu ruang tunggu dikosongkan dan ditutup.
+func admitLobby(ctx context.Context, groupId, sessionId primitive.ObjectID) []primitive.ObjectID {
+	admitted := []primitive.ObjectID{}
+	entries, err := findLobbyEntries(ctx, groupId)
+	if err != nil {
+		log.Printf("[LOBBY] failed to fetch lobby of group %s: %v", groupId.Hex(), err)
+		return admitted
+	}
+	for _, e := range entries {
+		ensureJoined(ctx, sessionId, groupId, e.UserID)
+		trackSessionParticipant(ctx, sessionId, e.UserID, false)
+		admitted = append(admitted, e.UserID)
+	}
+	if _, err := lobbyCol.DeleteMany(ctx, bson.M{"groupId": groupId}); err != nil {
+		log.Printf("[LOBBY] failed to clear lobby of group %s: %v", groupId.Hex(), err)
+	}
+	if _, err := groupCol.UpdateOne(ctx, bson.M{"_id": groupId}, bson.M{"$unset": bson.M{"lobbyOpen": ""}}); err != nil {
+		log.Printf("[LOBBY] failed to close lobby of group %s: %v", groupId.Hex(), err)
+	}
+	return admitted
+}
+
+// ensureLobbyIndexes memastikan satu entri ruang tunggu per user per grup
+func ensureLobbyIndexes(ctx context.Context) error {
+	_, err := lobbyCol.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "groupId", Value: 1}, {Key: "userId", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	})
+	return err
+}
@@ -0,0 +1,136 @@
+package controllers
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"sitor-backend/config"
+	"sitor-backend/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// withTransaction menjalankan fn di dalam transaksi MongoDB
+func withTransaction(ctx context.Context, fn func(sc mongo.SessionContext) error) error {
+	session, err := config.GetDB().Client().StartSession()
+	if err != nil {
+		return err
+	}
+	defer session.EndSession(ctx)
+	_, err = session.WithTransaction(ctx, func(sc mongo.SessionContext) (interface{}, error) {
+		return nil, fn(sc)
+	})
+	return err
+}
+
+// addGroupMember menambahkan user ke Group.Members dan User.JoinedGroups secara atomik.
+// Event member.joined hanya dikirim jika user benar-benar baru masuk grup.
+func addGroupMember(ctx context.Context, groupId, userId primitive.ObjectID) error {
+	joined := false
+	err := withTransaction(ctx, func(sc mongo.SessionContext) error {
+		res, err := groupCol.UpdateOne(sc, bson.M{"_id": groupId}, bson.M{"$addToSet": bson.M{"members": userId}})
+		if err != nil {
+			return err
+		}
+		joined = res.ModifiedCount > 0
+		_, err = userCol.UpdateOne(sc, bson.M{"_id": userId}, bson.M{"$addToSet": bson.M{"joinedGroups": groupId}})
+		return err
+	})
+	if err == nil && joined {
+		emitWebhookEvent(ctx, groupId, models.WebhookEventMemberJoined, map[string]interface{}{
+			"userId":   userId.Hex(),
+			"joinedAt": time.Now(),
+		})
+	}
+	return err
+}
+
+// removeGroupMember menghapus user dari Group.Members dan User.JoinedGroups secara atomik
+func removeGroupMember(ctx context.Context, groupId, userId primitive.ObjectID) error {
+	return withTransaction(ctx, func(sc mongo.SessionContext) error {
+		if _, err := groupCol.UpdateOne(sc, bson.M{"_id": groupId}, bson.M{"$pull": bson.M{"members": userId}}); err != nil {
+			return err
+		}
+		_, err := userCol.UpdateOne(sc, bson.M{"_id": userId}, bson.M{"$pull": bson.M{"joinedGroups": groupId}})
+		return err
+	})
+}
+
+// MembershipRepairReport berisi jumlah perbaikan yang dilakukan ReconcileMembership
+type MembershipRepairReport struct {
+	GroupsScanned      int `json:"groupsScanned"`
+	UsersScanned       int `json:"usersScanned"`
+	JoinedGroupsAdded  int `json:"joinedGroupsAdded"`
+	StaleGroupsRemoved int `json:"staleGroupsRemoved"`
+}
+
+// ReconcileMembership menyamakan User.JoinedGroups dengan Group.Members.
+// Group.Members dianggap sumber kebenaran. Jika dryRun true, hanya menghitung tanpa mengubah data.
+func ReconcileMembership(ctx context.Context, dryRun bool) (MembershipRepairReport, error) {
+	var report MembershipRepairReport
+
+	// Peta grup -> set anggota. Grup yang di-soft delete ikut dihitung karena masih bisa dipulihkan;
+	// grup yang sudah di-purge tidak ada lagi di koleksi
+	cursor, err := groupCol.Find(ctx, bson.M{})
+	if err != nil {
+		return report, err
+	}
+	var groups []models.Group
+	if err := cursor.All(ctx, &groups); err != nil {
+		return report, err
+	}
+	members := map[primitive.ObjectID]map[primitive.ObjectID]bool{}
+	for _, g := range groups {
+		report.GroupsScanned++
+		members[g.ID] = map[primitive.ObjectID]bool{}
+		for _, m := range g.Members {
+			members[g.ID][m] = true
+		}
+	}
+
+	cursor, err = userCol.Find(ctx, bson.M{})
+	if err != nil {
+		return report, err
+	}
+	var users []models.User
+	if err := cursor.All(ctx, &users); err != nil {
+		return report, err
+	}
+	for _, u := range users {
+		report.UsersScanned++
+		joined := map[primitive.ObjectID]bool{}
+		var stale []primitive.ObjectID
+		for _, gid := range u.JoinedGroups {
+			joined[gid] = true
+			if !members[gid][u.ID] {
+				stale = append(stale, gid)
+			}
+		}
+		var missing []primitive.ObjectID
+		for gid, set := range members {
+			if set[u.ID] && !joined[gid] {
+				missing = append(missing, gid)
+			}
+		}
+		report.StaleGroupsRemoved += len(stale)
+		report.JoinedGroupsAdded += len(missing)
+		if dryRun || (len(stale) == 0 && len(missing) == 0) {
+			continue
+		}
+		if len(stale) > 0 {
+			if _, err := userCol.UpdateOne(ctx, bson.M{"_id": u.ID}, bson.M{"$pull": bson.M{"joinedGroups": bson.M{"$in": stale}}}); err != nil {
+				return report, err
+			}
+		}
+		if len(missing) > 0 {
+			if _, err := userCol.UpdateOne(ctx, bson.M{"_id": u.ID}, bson.M{"$addToSet": bson.M{"joinedGroups": bson.M{"$each": missing}}}); err != nil {
+				return report, err
+			}
+		}
+		log.Printf("[REPAIR] user %s: removed %d stale, added %d missing groups", u.ID.Hex(), len(stale), len(missing))
+	}
+	return report, nil
+}
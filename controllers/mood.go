@@ -0,0 +1,162 @@
+package controllers
+
+import (
+	"context"
+	"time"
+
+	"sitor-backend/analytics"
+	"sitor-backend/config"
+	"sitor-backend/models"
+	"sitor-backend/taxonomy"
+
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// moodWindow adalah ringkasan emosi dan engagement dalam satu jendela waktu
+type moodWindow struct {
+	From         time.Time      `json:"from"`
+	To           time.Time      `json:"to"`
+	Samples      int            `json:"samples"`
+	Distribution models.Emotion `json:"distribution"`
+	Engagement   *float64       `json:"engagement"`
+}
+
+// groupMood adalah suasana kelas saat ini di sesi aktif
+type groupMood struct {
+	SessionID primitive.ObjectID `json:"sessionId"`
+	// Members adalah jumlah anggota dengan deteksi terbaru yang belum basi
+	Members        int            `json:"members"`
+	Distribution   models.Emotion `json:"distribution"`
+	Dominant       string         `json:"dominant"`
+	DominantCounts map[string]int `json:"dominantCounts"`
+	Engagement     *float64       `json:"engagement"`
+	// Current dan Previous adalah dua jendela berurutan dari sampel time series untuk melihat perubahan
+	Window   string         `json:"window"`
+	Current  moodWindow     `json:"current"`
+	Previous moodWindow     `json:"previous"`
+	Delta    models.Emotion `json:"delta"`
+	// EngagementDelta nil jika salah satu jendela tidak punya data engagement
+	EngagementDelta *float64 `json:"engagementDelta"`
+}
+
+// moodStats menambahkan rata-rata engagement ke stage $group rata-rata emosi
+func moodStats(id interface{}, labels []string) bson.M {
+	stage := analytics.AverageGroup(id, "emotions", labels)
+	stage["engagement"] = bson.M{"$avg": "$engagement"}
+	return stage
+}
+
+func engagementFromDoc(doc bson.M) *float64 {
+	if v, ok := doc["engagement"].(float64); ok {
+		return &v
+	}
+	return nil
+}
+
+// computeGroupMood menghitung suasana kelas dari deteksi terbaru tiap anggota
+// (yang lebih baru dari MOOD_STALE_AFTER) dan perubahan antar dua jendela waktu terakhir.
+func computeGroupMood(ctx context.Context, session models.Session, window time.Duration) (groupMood, error) {
+	tax := taxonomy.Current()
+	labels := tax.Labels()
+	now := time.Now()
+	mood := groupMood{SessionID: session.ID, Window: window.String(), Distribution: models.Emotion{}, Delta: models.Emotion{}}
+
+	match := sessionScopedFilter(session.GroupID, session.ID)
+	match["timestamp"] = bson.M{"$gte": now.Add(-config.GetEnvDuration("MOOD_STALE_AFTER", 2*time.Minute))}
+	cursor, err := detectionCol.Aggregate(ctx, mongo.Pipeline{
+		{{Key: "$match", Value: match}},
+		{{Key: "$facet", Value: bson.M{
+			"stats":     bson.A{bson.M{"$group": moodStats(nil, labels)}},
+			"histogram": bson.A{bson.M{"$group": analytics.HistogramGroup("emotions", tax.Categories)}},
+		}}},
+	})
+	if err != nil {
+		return mood, err
+	}
+	var latest []struct {
+		Stats     []bson.M `bson:"stats"`
+		Histogram []bson.M `bson:"histogram"`
+	}
+	if err := cursor.All(ctx, &latest); err != nil {
+		return mood, err
+	}
+	mood.DominantCounts = analytics.HistogramFromDocs(nil, tax.Categories)
+	if len(latest) > 0 {
+		if len(latest[0].Stats) > 0 {
+			stats := latest[0].Stats[0]
+			mood.Members = analytics.Count(stats)
+			mood.Distribution = analytics.EmotionFromDoc(stats, labels)
+			mood.Engagement = engagementFromDoc(stats)
+		}
+		mood.DominantCounts = analytics.HistogramFromDocs(latest[0].Histogram, tax.Categories)
+	}
+	mood.Dominant, _ = analytics.Dominant(mood.Distribution, tax.Categories)
+
+	// Perubahan: bandingkan jendela [now-window, now] dengan [now-2*window, now-window]
+	mood.Current = moodWindow{From: now.Add(-window), To: now, Distribution: models.Emotion{}}
+	mood.Previous = moodWindow{From: now.Add(-2 * window), To: now.Add(-window), Distribution: models.Emotion{}}
+	cursor, err = detectionSampleCol.Aggregate(ctx, mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{
+			"meta.sessionId": session.ID,
+			"timestamp":      bson.M{"$gte": mood.Previous.From, "$lte": now},
+		}}},
+		{{Key: "$group", Value: moodStats(bson.M{"$gte": bson.A{"$timestamp", mood.Current.From}}, labels)}},
+	})
+	if err != nil {
+		return mood, err
+	}
+	var windows []bson.M
+	if err := cursor.All(ctx, &windows); err != nil {
+		return mood, err
+	}
+	for _, w := range windows {
+		target := &mood.Previous
+		if isCurrent, _ := w["_id"].(bool); isCurrent {
+			target = &mood.Current
+		}
+		target.Samples = analytics.Count(w)
+		target.Distribution = analytics.EmotionFromDoc(w, labels)
+		target.Engagement = engagementFromDoc(w)
+	}
+	for label, v := range mood.Current.Distribution {
+		if prev, ok := mood.Previous.Distribution[label]; ok {
+			mood.Delta[label] = v - prev
+		}
+	}
+	if mood.Current.Engagement != nil && mood.Previous.Engagement != nil {
+		d := *mood.Current.Engagement - *mood.Previous.Engagement
+		mood.EngagementDelta = &d
+	}
+	return mood, nil
+}
+
+// GET /api/groups/:groupId/mood?window=5m
+// Suasana kelas di sesi aktif, dihitung di server agar leader tidak perlu mengambil semua deteksi
+func GetGroupMood(c *fiber.Ctx) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	group, status, msg := loadManagedGroup(ctx, c, "groupId")
+	if status != 0 {
+		return c.Status(status).JSON(fiber.Map{"success": false, "message": msg})
+	}
+	session, ok := findActiveSession(ctx, group.ID)
+	if !ok {
+		return c.Status(409).JSON(fiber.Map{"success": false, "message": "Session is not active"})
+	}
+	window := config.GetEnvDuration("MOOD_WINDOW", 5*time.Minute)
+	if q := c.Query("window"); q != "" {
+		d, err := time.ParseDuration(q)
+		if err != nil || d < 10*time.Second {
+			return c.Status(400).JSON(fiber.Map{"success": false, "message": "Invalid window, use a duration like 5m"})
+		}
+		window = d
+	}
+	mood, err := computeGroupMood(ctx, session, window)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"success": false, "message": "Failed to compute mood"})
+	}
+	return c.JSON(fiber.Map{"success": true, "mood": mood})
+}
@@ -0,0 +1,80 @@
+package controllers
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"sitor-backend/config"
+	"sitor-backend/models"
+
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var notificationCol = config.GetDB().Collection("notifications")
+
+// notifyUser menyimpan notifikasi untuk user, error hanya dicatat di log
+func notifyUser(ctx context.Context, n models.Notification) {
+	n.ID = primitive.NewObjectID()
+	n.CreatedAt = time.Now()
+	if _, err := notificationCol.InsertOne(ctx, n); err != nil {
+		log.Printf("[NOTIFY] failed to notify user %s: %v", n.UserID.Hex(), err)
+	}
+}
+
+// GET /api/me/notifications?unread=true
+func GetNotifications(c *fiber.Ctx) error {
+	userId := c.Locals("userId")
+	if userId == nil {
+		return c.Status(401).JSON(fiber.Map{"success": false, "message": "Unauthorized"})
+	}
+	uid, err := primitive.ObjectIDFromHex(userId.(string))
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"success": false, "message": "Invalid userId"})
+	}
+	filter := bson.M{"userId": uid}
+	if c.QueryBool("unread") {
+		filter["read"] = false
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	opts := options.Find().SetSort(bson.M{"createdAt": -1}).SetLimit(50)
+	cursor, err := notificationCol.Find(ctx, filter, opts)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"success": false, "message": "Failed to fetch notifications"})
+	}
+	notifications := []models.Notification{}
+	if err := cursor.All(ctx, &notifications); err != nil {
+		return c.Status(500).JSON(fiber.Map{"success": false, "message": "Failed to decode notifications"})
+	}
+	return c.JSON(fiber.Map{"success": true, "notifications": notifications})
+}
+
+// POST /api/me/notifications/:id/read
+func MarkNotificationRead(c *fiber.Ctx) error {
+	userId := c.Locals("userId")
+	if userId == nil {
+		return c.Status(401).JSON(fiber.Map{"success": false, "message": "Unauthorized"})
+	}
+	uid, err := primitive.ObjectIDFromHex(userId.(string))
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"success": false, "message": "Invalid userId"})
+	}
+	nid, err := primitive.ObjectIDFromHex(c.Params("id"))
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"success": false, "message": "Invalid notificationId"})
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	res, err := notificationCol.UpdateOne(ctx, bson.M{"_id": nid, "userId": uid}, bson.M{"$set": bson.M{"read": true}})
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"success": false, "message": "Failed to update notification"})
+	}
+	if res.MatchedCount == 0 {
+		return c.Status(404).JSON(fiber.Map{"success": false, "message": "Notification not found"})
+	}
+	return c.JSON(fiber.Map{"success": true})
+}
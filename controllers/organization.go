@@ -0,0 +1,477 @@
+package controllers
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"sitor-backend/analytics"
+	"sitor-backend/config"
+	"sitor-backend/models"
+	"sitor-backend/taxonomy"
+
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+var organizationCol = config.GetDB().Collection("organizations")
+
+func containsObjectID(ids []primitive.ObjectID, id primitive.ObjectID) bool {
+	for _, v := range ids {
+		if v == id {
+			return true
+		}
+	}
+	return false
+}
+
+// canManageGroup: leader grup atau admin organisasi pemilik grup boleh mengelola grup
+func canManageGroup(ctx context.Context, group models.Group, userId string) bool {
+	if group.LeaderID.Hex() == userId {
+		return true
+	}
+	if group.OrganizationID.IsZero() {
+		return false
+	}
+	uid, err := primitive.ObjectIDFromHex(userId)
+	if err != nil {
+		return false
+	}
+	count, err := organizationCol.CountDocuments(ctx, bson.M{"_id": group.OrganizationID, "admins": uid})
+	return err == nil && count > 0
+}
+
+// loadOrganization mengambil organisasi dari parameter :id dan memastikan user adalah anggotanya.
+// Jika adminOnly true, user harus admin organisasi.
+func loadOrganization(ctx context.Context, c *fiber.Ctx, adminOnly bool) (models.Organization, int, string) {
+	var org models.Organization
+	userId := c.Locals("userId")
+	if userId == nil {
+		return org, 401, "Unauthorized"
+	}
+	uid, err := primitive.ObjectIDFromHex(userId.(string))
+	if err != nil {
+		return org, 400, "Invalid userId"
+	}
+	oid, err := primitive.ObjectIDFromHex(c.Params("id"))
+	if err != nil {
+		return org, 400, "Invalid organizationId"
+	}
+	if err := organizationCol.FindOne(ctx, bson.M{"_id": oid}).Decode(&org); err != nil {
+		return org, 404, "Organization not found"
+	}
+	if adminOnly && !containsObjectID(org.Admins, uid) {
+		return org, 403, "Only organization admins can perform this action"
+	}
+	if !containsObjectID(org.Admins, uid) && !containsObjectID(org.Members, uid) {
+		return org, 403, "Not a member of this organization"
+	}
+	return org, 0, ""
+}
+
+// resolveUser mencari user berdasarkan userId atau email
+func resolveUser(ctx context.Context, userId, email string) (models.User, error) {
+	var user models.User
+	filter := bson.M{"email": strings.ToLower(strings.TrimSpace(email))}
+	if userId != "" {
+		uid, err := primitive.ObjectIDFromHex(userId)
+		if err != nil {
+			return user, err
+		}
+		filter = bson.M{"_id": uid}
+	}
+	err := userCol.FindOne(ctx, filter).Decode(&user)
+	return user, err
+}
+
+// POST /api/organizations
+func CreateOrganization(c *fiber.Ctx) error {
+	userId := c.Locals("userId")
+	if userId == nil {
+		return c.Status(401).JSON(fiber.Map{"success": false, "message": "Unauthorized"})
+	}
+	var body struct {
+		Name        string `json:"name"`
+		Description string `json:"description"`
+	}
+	if err := c.BodyParser(&body); err != nil || body.Name == "" {
+		return c.Status(400).JSON(fiber.Map{"success": false, "message": "Invalid request"})
+	}
+	uid, err := primitive.ObjectIDFromHex(userId.(string))
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"success": false, "message": "Invalid userId"})
+	}
+	org := models.Organization{
+		ID:          primitive.NewObjectID(),
+		Name:        body.Name,
+		Description: body.Description,
+		Admins:      []primitive.ObjectID{uid},
+		Members:     []primitive.ObjectID{uid},
+		CreatedBy:   uid,
+		CreatedAt:   time.Now(),
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if _, err := organizationCol.InsertOne(ctx, org); err != nil {
+		return c.Status(500).JSON(fiber.Map{"success": false, "message": "Failed to create organization"})
+	}
+	return c.JSON(fiber.Map{"success": true, "organization": org})
+}
+
+// GET /api/organizations
+// Daftar organisasi tempat user menjadi admin atau anggota
+func ListOrganizations(c *fiber.Ctx) error {
+	userId := c.Locals("userId")
+	if userId == nil {
+		return c.Status(401).JSON(fiber.Map{"success": false, "message": "Unauthorized"})
+	}
+	uid, err := primitive.ObjectIDFromHex(userId.(string))
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"success": false, "message": "Invalid userId"})
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	cursor, err := organizationCol.Find(ctx, bson.M{"$or": []bson.M{{"admins": uid}, {"members": uid}}})
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"success": false, "message": "Failed to fetch organizations"})
+	}
+	orgs := []models.Organization{}
+	if err := cursor.All(ctx, &orgs); err != nil {
+		return c.Status(500).JSON(fiber.Map{"success": false, "message": "Failed to decode organizations"})
+	}
+	return c.JSON(fiber.Map{"success": true, "organizations": orgs})
+}
+
+// GET /api/organizations/:id
+func GetOrganization(c *fiber.Ctx) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	org, status, msg := loadOrganization(ctx, c, false)
+	if status != 0 {
+		return c.Status(status).JSON(fiber.Map{"success": false, "message": msg})
+	}
+	return c.JSON(fiber.Map{"success": true, "organization": org})
+}
+
+// POST /api/organizations/:id/members
+// Body: {"userId": "..."} atau {"email": "..."}, "admin": true untuk menjadikan admin
+func AddOrganizationMember(c *fiber.Ctx) error {
+	var body struct {
+		UserId string `json:"userId"`
+		Email  string `json:"email"`
+		Admin  bool   `json:"admin"`
+	}
+	if err := c.BodyParser(&body); err != nil || (body.UserId == "" && body.Email == "") {
+		return c.Status(400).JSON(fiber.Map{"success": false, "message": "Invalid request"})
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	org, status, msg := loadOrganization(ctx, c, true)
+	if status != 0 {
+		return c.Status(status).JSON(fiber.Map{"success": false, "message": msg})
+	}
+	user, err := resolveUser(ctx, body.UserId, body.Email)
+	if err != nil {
+		return c.Status(404).JSON(fiber.Map{"success": false, "message": "User not found"})
+	}
+	update := bson.M{"members": user.ID}
+	if body.Admin {
+		update["admins"] = user.ID
+	}
+	_, err = organizationCol.UpdateOne(ctx, bson.M{"_id": org.ID}, bson.M{"$addToSet": update})
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"success": false, "message": "Failed to add member"})
+	}
+	return c.JSON(fiber.Map{"success": true})
+}
+
+// DELETE /api/organizations/:id/members/:userId
+func RemoveOrganizationMember(c *fiber.Ctx) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	org, status, msg := loadOrganization(ctx, c, true)
+	if status != 0 {
+		return c.Status(status).JSON(fiber.Map{"success": false, "message": msg})
+	}
+	uid, err := primitive.ObjectIDFromHex(c.Params("userId"))
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"success": false, "message": "Invalid userId"})
+	}
+	if containsObjectID(org.Admins, uid) && len(org.Admins) == 1 {
+		return c.Status(409).JSON(fiber.Map{"success": false, "message": "Organization must have at least one admin"})
+	}
+	_, err = organizationCol.UpdateOne(ctx, bson.M{"_id": org.ID}, bson.M{"$pull": bson.M{"members": uid, "admins": uid}})
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"success": false, "message": "Failed to remove member"})
+	}
+	return c.JSON(fiber.Map{"success": true})
+}
+
+// PUT /api/organizations/:id/admins/:userId
+// Body: {"admin": true|false}
+func SetOrganizationAdmin(c *fiber.Ctx) error {
+	var body struct {
+		Admin bool `json:"admin"`
+	}
+	if err := c.BodyParser(&body); err != nil {
+		return c.Status(400).JSON(fiber.Map{"success": false, "message": "Invalid request"})
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	org, status, msg := loadOrganization(ctx, c, true)
+	if status != 0 {
+		return c.Status(status).JSON(fiber.Map{"success": false, "message": msg})
+	}
+	uid, err := primitive.ObjectIDFromHex(c.Params("userId"))
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"success": false, "message": "Invalid userId"})
+	}
+	if !containsObjectID(org.Members, uid) {
+		return c.Status(404).JSON(fiber.Map{"success": false, "message": "User is not a member of this organization"})
+	}
+	update := bson.M{"$addToSet": bson.M{"admins": uid}}
+	if !body.Admin {
+		if containsObjectID(org.Admins, uid) && len(org.Admins) == 1 {
+			return c.Status(409).JSON(fiber.Map{"success": false, "message": "Organization must have at least one admin"})
+		}
+		update = bson.M{"$pull": bson.M{"admins": uid}}
+	}
+	if _, err := organizationCol.UpdateOne(ctx, bson.M{"_id": org.ID}, update); err != nil {
+		return c.Status(500).JSON(fiber.Map{"success": false, "message": "Failed to update admin"})
+	}
+	return c.JSON(fiber.Map{"success": true})
+}
+
+// findOrganizationGroups mengambil semua grup (yang belum dihapus) milik organisasi
+func findOrganizationGroups(ctx context.Context, orgId primitive.ObjectID) ([]models.Group, error) {
+	cursor, err := groupCol.Find(ctx, bson.M{"organizationId": orgId, "deletedAt": nil})
+	if err != nil {
+		return nil, err
+	}
+	groups := []models.Group{}
+	err = cursor.All(ctx, &groups)
+	return groups, err
+}
+
+// GET /api/organizations/:id/roster
+// Daftar anggota organisasi beserta grup organisasi yang diikuti
+func GetOrganizationRoster(c *fiber.Ctx) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	org, status, msg := loadOrganization(ctx, c, true)
+	if status != 0 {
+		return c.Status(status).JSON(fiber.Map{"success": false, "message": msg})
+	}
+	groups, err := findOrganizationGroups(ctx, org.ID)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"success": false, "message": "Failed to fetch groups"})
+	}
+	cursor, err := userCol.Find(ctx, bson.M{"_id": bson.M{"$in": org.Members}})
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"success": false, "message": "Failed to fetch members"})
+	}
+	var users []models.User
+	if err := cursor.All(ctx, &users); err != nil {
+		return c.Status(500).JSON(fiber.Map{"success": false, "message": "Failed to decode members"})
+	}
+	roster := []fiber.Map{}
+	for _, u := range users {
+		memberOf := []fiber.Map{}
+		for _, g := range groups {
+			if groupHasMember(g, u.ID) {
+				memberOf = append(memberOf, fiber.Map{"id": g.ID.Hex(), "name": g.Name, "leader": g.LeaderID == u.ID})
+			}
+		}
+		roster = append(roster, fiber.Map{
+			"id":     u.ID.Hex(),
+			"name":   u.Name,
+			"email":  u.Email,
+			"admin":  containsObjectID(org.Admins, u.ID),
+			"groups": memberOf,
+		})
+	}
+	return c.JSON(fiber.Map{"success": true, "roster": roster})
+}
+
+// GET /api/organizations/:id/groups
+func ListOrganizationGroups(c *fiber.Ctx) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	org, status, msg := loadOrganization(ctx, c, false)
+	if status != 0 {
+		return c.Status(status).JSON(fiber.Map{"success": false, "message": msg})
+	}
+	groups, err := findOrganizationGroups(ctx, org.ID)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"success": false, "message": "Failed to fetch groups"})
+	}
+	// Daftar anggota grup hanya untuk admin organisasi
+	uid, _ := primitive.ObjectIDFromHex(c.Locals("userId").(string))
+	admin := containsObjectID(org.Admins, uid)
+	views := make([]map[string]interface{}, 0, len(groups))
+	for _, g := range groups {
+		views = append(views, groupView(g, admin))
+	}
+	return c.JSON(fiber.Map{"success": true, "groups": views})
+}
+
+// POST /api/organizations/:id/groups
+// Memasukkan grup yang sudah ada ke organisasi. Hanya admin organisasi yang juga leader grup.
+func AttachOrganizationGroup(c *fiber.Ctx) error {
+	var body struct {
+		GroupId string `json:"groupId"`
+	}
+	if err := c.BodyParser(&body); err != nil {
+		return c.Status(400).JSON(fiber.Map{"success": false, "message": "Invalid request"})
+	}
+	gid, err := primitive.ObjectIDFromHex(body.GroupId)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"success": false, "message": "Invalid groupId"})
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	org, status, msg := loadOrganization(ctx, c, true)
+	if status != 0 {
+		return c.Status(status).JSON(fiber.Map{"success": false, "message": msg})
+	}
+	group, err := findGroup(ctx, gid)
+	if err != nil {
+		return c.Status(404).JSON(fiber.Map{"success": false, "message": "Group not found"})
+	}
+	// Admin organisasi lama tidak boleh memindahkan grup ke organisasi lain; hanya leader grup
+	if group.LeaderID.Hex() != c.Locals("userId").(string) {
+		return c.Status(403).JSON(fiber.Map{"success": false, "message": "Only the group leader can move the group into an organization"})
+	}
+	_, err = groupCol.UpdateOne(ctx, bson.M{"_id": gid}, bson.M{"$set": bson.M{"organizationId": org.ID}})
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"success": false, "message": "Failed to attach group"})
+	}
+	return c.JSON(fiber.Map{"success": true})
+}
+
+// DELETE /api/organizations/:id/groups/:groupId
+func DetachOrganizationGroup(c *fiber.Ctx) error {
+	gid, err := primitive.ObjectIDFromHex(c.Params("groupId"))
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"success": false, "message": "Invalid groupId"})
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	org, status, msg := loadOrganization(ctx, c, true)
+	if status != 0 {
+		return c.Status(status).JSON(fiber.Map{"success": false, "message": msg})
+	}
+	res, err := groupCol.UpdateOne(ctx, bson.M{"_id": gid, "organizationId": org.ID}, bson.M{"$unset": bson.M{"organizationId": ""}})
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"success": false, "message": "Failed to detach group"})
+	}
+	if res.MatchedCount == 0 {
+		return c.Status(404).JSON(fiber.Map{"success": false, "message": "Group not found in organization"})
+	}
+	return c.JSON(fiber.Map{"success": true})
+}
+
+// GET /api/organizations/:id/analytics
+// Ringkasan seluruh grup organisasi: jumlah grup, anggota, sesi aktif, sesi terarsip, dan rata-rata emosi
+func GetOrganizationAnalytics(c *fiber.Ctx) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+	org, status, msg := loadOrganization(ctx, c, true)
+	if status != 0 {
+		return c.Status(status).JSON(fiber.Map{"success": false, "message": msg})
+	}
+	groups, err := findOrganizationGroups(ctx, org.ID)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"success": false, "message": "Failed to fetch groups"})
+	}
+	groupIds := []primitive.ObjectID{}
+	uniqueMembers := map[primitive.ObjectID]bool{}
+	activeSessions := 0
+	for _, g := range groups {
+		groupIds = append(groupIds, g.ID)
+		for _, m := range g.Members {
+			uniqueMembers[m] = true
+		}
+		if g.SessionActive {
+			activeSessions++
+		}
+	}
+	db := config.GetDB()
+	archived, err := db.Collection("detection_history").CountDocuments(ctx, bson.M{"groupId": bson.M{"$in": groupIds}})
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"success": false, "message": "Failed to count sessions"})
+	}
+	perGroup, err := organizationEmotionAverages(ctx, groupIds)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"success": false, "message": "Failed to aggregate detections", "error": err.Error()})
+	}
+	groupStats := []fiber.Map{}
+	for _, g := range groups {
+		groupStats = append(groupStats, fiber.Map{
+			"id":             g.ID.Hex(),
+			"name":           g.Name,
+			"members":        len(g.Members),
+			"sessionActive":  g.SessionActive,
+			"averageEmotion": perGroup[g.ID],
+		})
+	}
+	return c.JSON(fiber.Map{
+		"success": true,
+		"analytics": fiber.Map{
+			"groups":           len(groups),
+			"members":          len(uniqueMembers),
+			"activeSessions":   activeSessions,
+			"archivedSessions": archived,
+			"averageEmotion":   perGroup[primitive.NilObjectID],
+			"perGroup":         groupStats,
+		},
+	})
+}
+
+// organizationEmotionAverages menghitung rata-rata emosi per grup dari deteksi aktif dan arsip sesi.
+// Hasil untuk seluruh organisasi disimpan dengan key NilObjectID.
+func organizationEmotionAverages(ctx context.Context, groupIds []primitive.ObjectID) (map[primitive.ObjectID]models.Emotion, error) {
+	result := map[primitive.ObjectID]models.Emotion{}
+	if len(groupIds) == 0 {
+		return result, nil
+	}
+	labels := taxonomy.Current().Labels()
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"groupId": bson.M{"$in": groupIds}}}},
+		{{Key: "$unwind", Value: "$detections"}},
+		{{Key: "$replaceRoot", Value: bson.M{"newRoot": "$detections"}}},
+		{{Key: "$unionWith", Value: bson.M{
+			"coll":     "detections",
+			"pipeline": bson.A{bson.M{"$match": bson.M{"groupId": bson.M{"$in": groupIds}}}},
+		}}},
+		{{Key: "$facet", Value: bson.M{
+			"perGroup": bson.A{bson.M{"$group": analytics.AverageGroup("$groupId", "emotions", labels)}},
+			"overall":  bson.A{bson.M{"$group": analytics.AverageGroup(nil, "emotions", labels)}},
+		}}},
+	}
+	cursor, err := config.GetDB().Collection("detection_history").Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	var facets []struct {
+		PerGroup []bson.M `bson:"perGroup"`
+		Overall  []bson.M `bson:"overall"`
+	}
+	if err := cursor.All(ctx, &facets); err != nil {
+		return nil, err
+	}
+	if len(facets) == 0 {
+		return result, nil
+	}
+	for _, g := range facets[0].PerGroup {
+		if id, ok := g["_id"].(primitive.ObjectID); ok {
+			result[id] = analytics.EmotionFromDoc(g, labels)
+		}
+	}
+	if len(facets[0].Overall) > 0 {
+		result[primitive.NilObjectID] = analytics.EmotionFromDoc(facets[0].Overall[0], labels)
+	}
+	return result, nil
+}
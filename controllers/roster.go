@@ -0,0 +1,295 @@
+package controllers
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"io"
+	"log"
+	"net/mail"
+	"strings"
+	"time"
+
+	"sitor-backend/config"
+	"sitor-backend/models"
+	"sitor-backend/utils"
+
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var groupInviteCol = config.GetDB().Collection("group_invites")
+
+// Batas jumlah baris per import agar request tidak terlalu berat
+const maxRosterRows = 1000
+
+// loadManagedGroup mengambil grup dari parameter :id dan memastikan user boleh mengelolanya
+func loadManagedGroup(ctx context.Context, c *fiber.Ctx, param string) (models.Group, int, string) {
+	var group models.Group
+	userId := c.Locals("userId")
+	if userId == nil {
+		return group, 401, "Unauthorized"
+	}
+	gid, err := primitive.ObjectIDFromHex(c.Params(param))
+	if err != nil {
+		return group, 400, "Invalid groupId"
+	}
+	group, err = findGroup(ctx, gid)
+	if err != nil {
+		return group, 404, "Group not found"
+	}
+	if !canManageGroup(ctx, group, userId.(string)) {
+		return group, 403, "Only the group leader or an organization admin can manage this group"
+	}
+	return group, 0, ""
+}
+
+// rosterCSV membaca isi CSV dari form file "file" atau langsung dari body request
+func rosterCSV(c *fiber.Ctx) ([]byte, error) {
+	if fh, err := c.FormFile("file"); err == nil {
+		f, err := fh.Open()
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		return io.ReadAll(f)
+	}
+	return c.Body(), nil
+}
+
+// POST /api/groups/:id/roster/import
+// CSV wajib memiliki header dengan kolom "email", kolom "name" opsional.
+// Email yang sudah terdaftar langsung ditambahkan ke grup, email lain dibuatkan undangan.
+func ImportGroupRoster(c *fiber.Ctx) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+	group, status, msg := loadManagedGroup(ctx, c, "id")
+	if status != 0 {
+		return c.Status(status).JSON(fiber.Map{"success": false, "message": msg})
+	}
+	data, err := rosterCSV(c)
+	if err != nil || len(bytes.TrimSpace(data)) == 0 {
+		return c.Status(400).JSON(fiber.Map{"success": false, "message": "CSV file is required"})
+	}
+	reader := csv.NewReader(bytes.NewReader(data))
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+	header, err := reader.Read()
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"success": false, "message": "Invalid CSV header", "error": err.Error()})
+	}
+	emailCol, nameCol := -1, -1
+	for i, h := range header {
+		switch strings.ToLower(strings.TrimSpace(strings.TrimPrefix(h, "\ufeff"))) {
+		case "email":
+			emailCol = i
+		case "name":
+			nameCol = i
+		}
+	}
+	if emailCol < 0 {
+		return c.Status(400).JSON(fiber.Map{"success": false, "message": "CSV header must contain an \"email\" column"})
+	}
+	inviter, _ := primitive.ObjectIDFromHex(c.Locals("userId").(string))
+
+	// Baca semua baris dulu agar file yang melebihi batas ditolak sebelum ada data yang ditulis
+	type rosterRow struct {
+		record []string
+		err    error
+	}
+	rows := []rosterRow{}
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		rows = append(rows, rosterRow{record: record, err: err})
+		if len(rows) > maxRosterRows {
+			return c.Status(413).JSON(fiber.Map{"success": false, "message": "Too many rows, maximum is 1000"})
+		}
+	}
+
+	results := []fiber.Map{}
+	summary := map[string]int{"added": 0, "invited": 0, "alreadyMember": 0, "errors": 0}
+	seen := map[string]bool{}
+	for i, row := range rows {
+		record, err := row.record, row.err
+		rowNum := i + 2 // header = baris 1
+		result := fiber.Map{"row": rowNum}
+		fail := func(reason string) {
+			result["status"] = "error"
+			result["error"] = reason
+			summary["errors"]++
+			results = append(results, result)
+		}
+		if err != nil {
+			fail(err.Error())
+			continue
+		}
+		if emailCol >= len(record) {
+			fail("Missing email")
+			continue
+		}
+		email := strings.ToLower(strings.TrimSpace(record[emailCol]))
+		name := ""
+		if nameCol >= 0 && nameCol < len(record) {
+			name = strings.TrimSpace(record[nameCol])
+		}
+		result["email"] = email
+		if email == "" {
+			fail("Missing email")
+			continue
+		}
+		if addr, err := mail.ParseAddress(email); err != nil || addr.Address != email {
+			fail("Invalid email")
+			continue
+		}
+		if seen[email] {
+			fail("Duplicate email in file")
+			continue
+		}
+		seen[email] = true
+
+		var user models.User
+		err = userCol.FindOne(ctx, bson.M{"email": email}).Decode(&user)
+		switch {
+		case err == nil && groupHasMember(group, user.ID):
+			result["status"] = "already_member"
+			summary["alreadyMember"]++
+		case err == nil:
+			if err := addGroupMember(ctx, group.ID, user.ID); err != nil {
+				fail("Failed to add member")
+				continue
+			}
+			result["status"] = "added"
+			result["userId"] = user.ID.Hex()
+			summary["added"]++
+		case err == mongo.ErrNoDocuments:
+			// Import ulang membuat token baru; token lama tidak berlaku lagi
+			token, err := utils.GenerateSecret(16)
+			if err != nil {
+				fail("Failed to create invite")
+				continue
+			}
+			_, err = groupInviteCol.UpdateOne(ctx,
+				bson.M{"groupId": group.ID, "email": email, "status": "pending"},
+				bson.M{
+					"$set":         bson.M{"name": name, "invitedBy": inviter, "tokenHash": utils.HashToken(token)},
+					"$setOnInsert": bson.M{"createdAt": time.Now()},
+				},
+				options.Update().SetUpsert(true))
+			if err != nil {
+				fail("Failed to create invite")
+				continue
+			}
+			result["status"] = "invited"
+			result["inviteToken"] = token
+			summary["invited"]++
+		default:
+			fail("Failed to look up user")
+			continue
+		}
+		results = append(results, result)
+	}
+	return c.JSON(fiber.Map{"success": true, "summary": summary, "rows": results})
+}
+
+// GET /api/groups/:id/roster/export
+// CSV berisi anggota grup dan undangan yang masih pending
+func ExportGroupRoster(c *fiber.Ctx) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+	group, status, msg := loadManagedGroup(ctx, c, "id")
+	if status != 0 {
+		return c.Status(status).JSON(fiber.Map{"success": false, "message": msg})
+	}
+	var users []models.User
+	if len(group.Members) > 0 {
+		cursor, err := userCol.Find(ctx, bson.M{"_id": bson.M{"$in": group.Members}})
+		if err != nil {
+			return c.Status(500).JSON(fiber.Map{"success": false, "message": "Failed to fetch members"})
+		}
+		if err := cursor.All(ctx, &users); err != nil {
+			return c.Status(500).JSON(fiber.Map{"success": false, "message": "Failed to decode members"})
+		}
+	}
+	cursor, err := groupInviteCol.Find(ctx, bson.M{"groupId": group.ID, "status": "pending"})
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"success": false, "message": "Failed to fetch invites"})
+	}
+	var invites []models.GroupInvite
+	if err := cursor.All(ctx, &invites); err != nil {
+		return c.Status(500).JSON(fiber.Map{"success": false, "message": "Failed to decode invites"})
+	}
+
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	w.Write([]string{"email", "name", "role", "status"})
+	for _, u := range users {
+		role := "member"
+		if u.ID == group.LeaderID {
+			role = "leader"
+		}
+		w.Write([]string{u.Email, u.Name, role, "member"})
+	}
+	for _, inv := range invites {
+		w.Write([]string{inv.Email, inv.Name, "member", "invited"})
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return c.Status(500).JSON(fiber.Map{"success": false, "message": "Failed to write CSV"})
+	}
+	c.Set(fiber.HeaderContentType, "text/csv; charset=utf-8")
+	c.Set(fiber.HeaderContentDisposition, `attachment; filename="roster-`+group.ID.Hex()+`.csv"`)
+	return c.Send(buf.Bytes())
+}
+
+// POST /api/invites/accept
+// Body: {"token": "..."}. User yang sudah login menerima undangan grup untuk emailnya.
+func AcceptGroupInvite(c *fiber.Ctx) error {
+	userId, ok := c.Locals("userId").(string)
+	if !ok {
+		return c.Status(401).JSON(fiber.Map{"success": false, "message": "Unauthorized"})
+	}
+	uid, err := primitive.ObjectIDFromHex(userId)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"success": false, "message": "Invalid userId"})
+	}
+	var body struct {
+		Token string `json:"token"`
+	}
+	if err := c.BodyParser(&body); err != nil || strings.TrimSpace(body.Token) == "" {
+		return c.Status(400).JSON(fiber.Map{"success": false, "message": "token is required"})
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	var user models.User
+	if err := userCol.FindOne(ctx, bson.M{"_id": uid}).Decode(&user); err != nil {
+		return c.Status(401).JSON(fiber.Map{"success": false, "message": "User not found"})
+	}
+	var invite models.GroupInvite
+	err = groupInviteCol.FindOne(ctx, bson.M{"tokenHash": utils.HashToken(strings.TrimSpace(body.Token)), "status": "pending"}).Decode(&invite)
+	// Token milik email lain diperlakukan sama dengan token yang tidak ada
+	if err != nil || invite.Email != user.Email {
+		return c.Status(404).JSON(fiber.Map{"success": false, "message": "Invite not found"})
+	}
+	group, err := findGroup(ctx, invite.GroupID)
+	if err != nil {
+		return c.Status(404).JSON(fiber.Map{"success": false, "message": "Group not found"})
+	}
+	if !groupHasMember(group, uid) {
+		if err := addGroupMember(ctx, group.ID, uid); err != nil {
+			log.Printf("[INVITE] failed to add %s to group %s: %v", user.Email, group.ID.Hex(), err)
+			return c.Status(500).JSON(fiber.Map{"success": false, "message": "Failed to join group"})
+		}
+	}
+	now := time.Now()
+	groupInviteCol.UpdateOne(ctx, bson.M{"_id": invite.ID}, bson.M{
+		"$set":   bson.M{"status": "accepted", "acceptedAt": now},
+		"$unset": bson.M{"tokenHash": ""},
+	})
+	return c.JSON(fiber.Map{"success": true, "groupId": group.ID.Hex(), "name": group.Name})
+}
@@ -0,0 +1,402 @@
+package controllers
+
+import (
+	"context"
+	"log"
+	"sort"
+	"time"
+	_ "time/tzdata" // zona waktu tetap tersedia walau server tidak punya tzdata
+
+	"sitor-backend/config"
+	"sitor-backend/models"
+
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var scheduleCol = config.GetDB().Collection("session_schedules")
+
+// Batas durasi satu sesi terjadwal (menit)
+const maxScheduleDurationMinutes = 12 * 60
+
+type scheduleBody struct {
+	Name            string                `json:"name"`
+	Timezone        string                `json:"timezone"`
+	Slots           []models.ScheduleSlot `json:"slots"`
+	DurationMinutes int                   `json:"durationMinutes"`
+	Exceptions      []string              `json:"exceptions"`
+	Active          *bool                 `json:"active"`
+}
+
+// validate memeriksa isi jadwal, mengembalikan pesan error jika tidak valid
+func (b *scheduleBody) validate() string {
+	if b.Timezone == "" {
+		b.Timezone = "UTC"
+	}
+	if _, err := time.LoadLocation(b.Timezone); err != nil {
+		return "Invalid timezone"
+	}
+	if len(b.Slots) == 0 {
+		return "At least one slot is required"
+	}
+	for _, s := range b.Slots {
+		if s.Weekday < 0 || s.Weekday > 6 {
+			return "Slot weekday must be between 0 (Sunday) and 6 (Saturday)"
+		}
+		if _, err := time.Parse("15:04", s.StartTime); err != nil {
+			return "Slot startTime must use HH:MM format"
+		}
+	}
+	if b.DurationMinutes <= 0 || b.DurationMinutes > maxScheduleDurationMinutes {
+		return "durationMinutes must be between 1 and 720"
+	}
+	if b.Exceptions == nil {
+		b.Exceptions = []string{}
+	}
+	for _, d := range b.Exceptions {
+		if _, err := time.Parse("2006-01-02", d); err != nil {
+			return "Exception dates must use YYYY-MM-DD format"
+		}
+	}
+	return ""
+}
+
+type scheduleOccurrence struct {
+	ScheduleID primitive.ObjectID `json:"scheduleId"`
+	Name       string             `json:"name"`
+	StartsAt   time.Time          `json:"startsAt"`
+	EndsAt     time.Time          `json:"endsAt"`
+	Skipped    bool               `json:"skipped"` // jatuh pada tanggal pengecualian (libur)
+}
+
+// scheduleOccurrences menghitung semua occurrence jadwal yang mulai di rentang [from, to)
+func scheduleOccurrences(s models.SessionSchedule, from, to time.Time) []scheduleOccurrence {
+	loc, err := time.LoadLocation(s.Timezone)
+	if err != nil {
+		loc = time.UTC
+	}
+	exceptions := map[string]bool{}
+	for _, d := range s.Exceptions {
+		exceptions[d] = true
+	}
+	duration := time.Duration(s.DurationMinutes) * time.Minute
+	occurrences := []scheduleOccurrence{}
+	local := from.In(loc)
+	for day := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, loc); day.Before(to); day = day.AddDate(0, 0, 1) {
+		for _, slot := range s.Slots {
+			if int(day.Weekday()) != slot.Weekday {
+				continue
+			}
+			t, err := time.Parse("15:04", slot.StartTime)
+			if err != nil {
+				continue
+			}
+			startsAt := time.Date(day.Year(), day.Month(), day.Day(), t.Hour(), t.Minute(), 0, 0, loc)
+			if startsAt.Before(from) || !startsAt.Before(to) {
+				continue
+			}
+			occurrences = append(occurrences, scheduleOccurrence{
+				ScheduleID: s.ID,
+				Name:       s.Name,
+				StartsAt:   startsAt,
+				EndsAt:     startsAt.Add(duration),
+				Skipped:    exceptions[day.Format("2006-01-02")],
+			})
+		}
+	}
+	sort.Slice(occurrences, func(i, j int) bool { return occurrences[i].StartsAt.Before(occurrences[j].StartsAt) })
+	return occurrences
+}
+
+// loadSchedule mengambil jadwal dari parameter :scheduleId milik grup yang boleh dikelola user
+func loadSchedule(ctx context.Context, c *fiber.Ctx) (models.SessionSchedule, int, string) {
+	var schedule models.SessionSchedule
+	group, status, msg := loadManagedGroup(ctx, c, "groupId")
+	if status != 0 {
+		return schedule, status, msg
+	}
+	sid, err := primitive.ObjectIDFromHex(c.Params("scheduleId"))
+	if err != nil {
+		return schedule, 400, "Invalid scheduleId"
+	}
+	if err := scheduleCol.FindOne(ctx, bson.M{"_id": sid, "groupId": group.ID}).Decode(&schedule); err != nil {
+		return schedule, 404, "Schedule not found"
+	}
+	return schedule, 0, ""
+}
+
+// POST /api/groups/:groupId/schedules
+func CreateSchedule(c *fiber.Ctx) error {
+	var body scheduleBody
+	if err := c.BodyParser(&body); err != nil {
+		return c.Status(400).JSON(fiber.Map{"success": false, "message": "Invalid request"})
+	}
+	if msg := body.validate(); msg != "" {
+		return c.Status(400).JSON(fiber.Map{"success": false, "message": msg})
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	group, status, msg := loadManagedGroup(ctx, c, "groupId")
+	if status != 0 {
+		return c.Status(status).JSON(fiber.Map{"success": false, "message": msg})
+	}
+	createdBy, _ := primitive.ObjectIDFromHex(c.Locals("userId").(string))
+	schedule := models.SessionSchedule{
+		ID:              primitive.NewObjectID(),
+		GroupID:         group.ID,
+		Name:            body.Name,
+		Timezone:        body.Timezone,
+		Slots:           body.Slots,
+		DurationMinutes: body.DurationMinutes,
+		Exceptions:      body.Exceptions,
+		Active:          body.Active == nil || *body.Active,
+		CreatedBy:       createdBy,
+		CreatedAt:       time.Now(),
+	}
+	if _, err := scheduleCol.InsertOne(ctx, schedule); err != nil {
+		return c.Status(500).JSON(fiber.Map{"success": false, "message": "Failed to create schedule"})
+	}
+	return c.JSON(fiber.Map{"success": true, "schedule": schedule})
+}
+
+// GET /api/groups/:groupId/schedules
+func ListSchedules(c *fiber.Ctx) error {
+	userId, ok := c.Locals("userId").(string)
+	if !ok {
+		return c.Status(401).JSON(fiber.Map{"success": false, "message": "Unauthorized"})
+	}
+	gid, err := primitive.ObjectIDFromHex(c.Params("groupId"))
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"success": false, "message": "Invalid groupId"})
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	group, err := findGroup(ctx, gid)
+	if err != nil {
+		return c.Status(404).JSON(fiber.Map{"success": false, "message": "Group not found"})
+	}
+	if !canViewGroup(ctx, group, userId) {
+		return c.Status(403).JSON(fiber.Map{"success": false, "message": "Not a member of this group"})
+	}
+	cursor, err := scheduleCol.Find(ctx, bson.M{"groupId": gid})
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"success": false, "message": "Failed to fetch schedules"})
+	}
+	schedules := []models.SessionSchedule{}
+	if err := cursor.All(ctx, &schedules); err != nil {
+		return c.Status(500).JSON(fiber.Map{"success": false, "message": "Failed to decode schedules"})
+	}
+	return c.JSON(fiber.Map{"success": true, "schedules": schedules})
+}
+
+// PUT /api/groups/:groupId/schedules/:scheduleId
+func UpdateSchedule(c *fiber.Ctx) error {
+	var body scheduleBody
+	if err := c.BodyParser(&body); err != nil {
+		return c.Status(400).JSON(fiber.Map{"success": false, "message": "Invalid request"})
+	}
+	if msg := body.validate(); msg != "" {
+		return c.Status(400).JSON(fiber.Map{"success": false, "message": msg})
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	schedule, status, msg := loadSchedule(ctx, c)
+	if status != 0 {
+		return c.Status(status).JSON(fiber.Map{"success": false, "message": msg})
+	}
+	schedule.Name = body.Name
+	schedule.Timezone = body.Timezone
+	schedule.Slots = body.Slots
+	schedule.DurationMinutes = body.DurationMinutes
+	schedule.Exceptions = body.Exceptions
+	if body.Active != nil {
+		schedule.Active = *body.Active
+	}
+	_, err := scheduleCol.UpdateOne(ctx, bson.M{"_id": schedule.ID}, bson.M{"$set": bson.M{
+		"name":            schedule.Name,
+		"timezone":        schedule.Timezone,
+		"slots":           schedule.Slots,
+		"durationMinutes": schedule.DurationMinutes,
+		"exceptions":      schedule.Exceptions,
+		"active":          schedule.Active,
+	}})
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"success": false, "message": "Failed to update schedule"})
+	}
+	return c.JSON(fiber.Map{"success": true, "schedule": schedule})
+}
+
+// DELETE /api/groups/:groupId/schedules/:scheduleId
+func DeleteSchedule(c *fiber.Ctx) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	schedule, status, msg := loadSchedule(ctx, c)
+	if status != 0 {
+		return c.Status(status).JSON(fiber.Map{"success": false, "message": msg})
+	}
+	if _, err := scheduleCol.DeleteOne(ctx, bson.M{"_id": schedule.ID}); err != nil {
+		return c.Status(500).JSON(fiber.Map{"success": false, "message": "Failed to delete schedule"})
+	}
+	return c.JSON(fiber.Map{"success": true})
+}
+
+// POST /api/groups/:groupId/schedules/:scheduleId/exceptions
+// Body: {"date": "YYYY-MM-DD"}, menandai tanggal libur sehingga sesi tidak dimulai
+func AddScheduleException(c *fiber.Ctx) error {
+	var body struct {
+		Date string `json:"date"`
+	}
+	if err := c.BodyParser(&body); err != nil {
+		return c.Status(400).JSON(fiber.Map{"success": false, "message": "Invalid request"})
+	}
+	if _, err := time.Parse("2006-01-02", body.Date); err != nil {
+		return c.Status(400).JSON(fiber.Map{"success": false, "message": "Date must use YYYY-MM-DD format"})
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	schedule, status, msg := loadSchedule(ctx, c)
+	if status != 0 {
+		return c.Status(status).JSON(fiber.Map{"success": false, "message": msg})
+	}
+	if _, err := scheduleCol.UpdateOne(ctx, bson.M{"_id": schedule.ID}, bson.M{"$addToSet": bson.M{"exceptions": body.Date}}); err != nil {
+		return c.Status(500).JSON(fiber.Map{"success": false, "message": "Failed to add exception"})
+	}
+	return c.JSON(fiber.Map{"success": true})
+}
+
+// DELETE /api/groups/:groupId/schedules/:scheduleId/exceptions/:date
+func RemoveScheduleException(c *fiber.Ctx) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	schedule, status, msg := loadSchedule(ctx, c)
+	if status != 0 {
+		return c.Status(status).JSON(fiber.Map{"success": false, "message": msg})
+	}
+	if _, err := scheduleCol.UpdateOne(ctx, bson.M{"_id": schedule.ID}, bson.M{"$pull": bson.M{"exceptions": c.Params("date")}}); err != nil {
+		return c.Status(500).JSON(fiber.Map{"success": false, "message": "Failed to remove exception"})
+	}
+	return c.JSON(fiber.Map{"success": true})
+}
+
+// GET /api/groups/:groupId/schedules/upcoming?days=7
+func GetUpcomingSessions(c *fiber.Ctx) error {
+	userId, ok := c.Locals("userId").(string)
+	if !ok {
+		return c.Status(401).JSON(fiber.Map{"success": false, "message": "Unauthorized"})
+	}
+	gid, err := primitive.ObjectIDFromHex(c.Params("groupId"))
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"success": false, "message": "Invalid groupId"})
+	}
+	days := c.QueryInt("days", 7)
+	if days < 1 || days > 60 {
+		return c.Status(400).JSON(fiber.Map{"success": false, "message": "days must be between 1 and 60"})
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	group, err := findGroup(ctx, gid)
+	if err != nil {
+		return c.Status(404).JSON(fiber.Map{"success": false, "message": "Group not found"})
+	}
+	if !canViewGroup(ctx, group, userId) {
+		return c.Status(403).JSON(fiber.Map{"success": false, "message": "Not a member of this group"})
+	}
+	cursor, err := scheduleCol.Find(ctx, bson.M{"groupId": gid, "active": true})
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"success": false, "message": "Failed to fetch schedules"})
+	}
+	var schedules []models.SessionSchedule
+	if err := cursor.All(ctx, &schedules); err != nil {
+		return c.Status(500).JSON(fiber.Map{"success": false, "message": "Failed to decode schedules"})
+	}
+	now := time.Now()
+	upcoming := []scheduleOccurrence{}
+	for _, s := range schedules {
+		upcoming = append(upcoming, scheduleOccurrences(s, now, now.AddDate(0, 0, days))...)
+	}
+	sort.Slice(upcoming, func(i, j int) bool { return upcoming[i].StartsAt.Before(upcoming[j].StartsAt) })
+	return c.JSON(fiber.Map{"success": true, "upcoming": upcoming})
+}
+
+// StartSessionScheduler menjalankan scheduler yang memulai/mengakhiri sesi sesuai jadwal
+func StartSessionScheduler() {
+	runEvery("SCHEDULER", config.GetEnvDuration("SCHEDULER_INTERVAL", time.Minute), runSessionSchedules)
+}
+
+func runSessionSchedules() {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	// Jadwal nonaktif tetap diproses jika sesinya sedang berjalan agar bisa diakhiri
+	cursor, err := scheduleCol.Find(ctx, bson.M{"$or": []bson.M{{"active": true}, {"runningUntil": bson.M{"$ne": nil}}}})
+	if err != nil {
+		log.Printf("[SCHEDULER] failed to fetch schedules: %v", err)
+		return
+	}
+	var schedules []models.SessionSchedule
+	if err := cursor.All(ctx, &schedules); err != nil {
+		log.Printf("[SCHEDULER] failed to decode schedules: %v", err)
+		return
+	}
+	for _, s := range schedules {
+		runSchedule(ctx, s, time.Now())
+	}
+}
+
+func runSchedule(ctx context.Context, s models.SessionSchedule, now time.Time) {
+	// Akhiri sesi yang dimulai scheduler jika durasinya sudah habis. Hanya sesi milik jadwal ini yang
+	// diakhiri; jika sesi itu sudah berakhir (mis. diakhiri leader lalu diganti sesi manual), jadwal dilepas saja.
+	if s.RunningUntil != nil {
+		current := activeSessionID(ctx, s.GroupID)
+		release := s.RunningSessionID.IsZero() || current != s.RunningSessionID
+		if !release && !now.Before(*s.RunningUntil) {
+			_, err := endGroupSessionIf(ctx, s.GroupID, s.RunningSessionID, models.SessionEndSchedule)
+			if err != nil && err != errSessionNotActive {
+				log.Printf("[SCHEDULER] failed to end session for group %s: %v", s.GroupID.Hex(), err)
+				return
+			}
+			if err == nil {
+				log.Printf("[SCHEDULER] session ended for group %s (schedule %s)", s.GroupID.Hex(), s.ID.Hex())
+			}
+			release = true
+		}
+		if release {
+			scheduleCol.UpdateOne(ctx, bson.M{"_id": s.ID}, bson.M{"$unset": bson.M{"runningUntil": "", "runningSessionId": ""}})
+		}
+	}
+	if !s.Active {
+		return
+	}
+
+	// Cari occurrence yang sedang berlangsung dan belum diproses
+	duration := time.Duration(s.DurationMinutes) * time.Minute
+	occurrences := scheduleOccurrences(s, now.Add(-duration), now.Add(time.Nanosecond))
+	if len(occurrences) == 0 {
+		return
+	}
+	occ := occurrences[len(occurrences)-1]
+	if occ.Skipped || occ.StartsAt.Equal(s.LastStartedAt) || !now.Before(occ.EndsAt) {
+		return
+	}
+	group, err := findGroup(ctx, s.GroupID)
+	if err != nil {
+		return
+	}
+	update := bson.M{"lastStartedAt": occ.StartsAt}
+	// Sesi yang sudah dimulai manual tidak diambil alih oleh scheduler. Sesi lama tanpa dokumen sessions
+	// tidak dianggap berjalan; startGroupSession mengakhirinya sebelum memulai sesi jadwal.
+	if !group.SessionActive || group.ActiveSessionID.IsZero() {
+		session, err := startGroupSession(ctx, s.GroupID, primitive.NilObjectID, models.SessionTriggerSchedule)
+		if err != nil {
+			log.Printf("[SCHEDULER] failed to start session for group %s: %v", s.GroupID.Hex(), err)
+			return
+		}
+		// Jika sesi lain lebih dulu dimulai (mis. manual), sesi itu yang dikembalikan dan tidak diambil alih
+		if session.Trigger == models.SessionTriggerSchedule && session.StartedBy.IsZero() && !session.StartedAt.Before(now) {
+			update["runningUntil"] = occ.EndsAt
+			update["runningSessionId"] = session.ID
+			log.Printf("[SCHEDULER] session started for group %s (schedule %s)", s.GroupID.Hex(), s.ID.Hex())
+		}
+	}
+	scheduleCol.UpdateOne(ctx, bson.M{"_id": s.ID}, bson.M{"$set": update})
+}
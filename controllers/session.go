@@ -2,6 +2,7 @@ package controllers
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"time"
 
@@ -11,98 +12,351 @@ import (
 	"github.com/gofiber/fiber/v2"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
-// POST /api/groups/:groupId/end-session
-func EndSession(c *fiber.Ctx) error {
-	groupId := c.Params("groupId")
-	fmt.Println("[END-SESSION] groupId:", groupId)
-	if groupId == "" {
-		return c.Status(400).JSON(fiber.Map{"success": false, "message": "Group ID required"})
+var sessionCol = config.GetDB().Collection("sessions")
+
+// newSession membuat dokumen sesi aktif baru (belum disimpan)
+func newSession(groupId, startedBy primitive.ObjectID, trigger string) models.Session {
+	return models.Session{
+		ID:           primitive.NewObjectID(),
+		GroupID:      groupId,
+		StartedBy:    startedBy,
+		Trigger:      trigger,
+		StartedAt:    time.Now(),
+		Status:       models.SessionStatusActive,
+		Participants: []primitive.ObjectID{},
 	}
+}
 
-	objGroupId, err := primitive.ObjectIDFromHex(groupId)
+// activeSessionID mengembalikan ID sesi aktif grup, atau NilObjectID jika tidak ada.
+// Sesi lama tanpa dokumen sessions dibuatkan dokumennya lebih dulu lewat adoptLegacySession.
+func activeSessionID(ctx context.Context, groupId primitive.ObjectID) primitive.ObjectID {
+	var group struct {
+		ActiveSessionID primitive.ObjectID `bson:"activeSessionId"`
+	}
+	err := groupCol.FindOne(ctx, bson.M{"_id": groupId, "sessionActive": true}).Decode(&group)
 	if err != nil {
-		return c.Status(400).JSON(fiber.Map{"success": false, "message": "Invalid groupId"})
+		return primitive.NilObjectID
+	}
+	if group.ActiveSessionID.IsZero() {
+		sessionId, err := adoptLegacySession(ctx, groupId)
+		if err != nil {
+			fmt.Println("[SESSION] gagal membuat dokumen sesi lama:", err)
+			return primitive.NilObjectID
+		}
+		return sessionId
 	}
+	return group.ActiveSessionID
+}
 
-	db := config.GetDB()
+// legacySessionFilter mencocokkan grup yang sesinya aktif dari sebelum ada koleksi sessions
+func legacySessionFilter(groupId primitive.ObjectID) bson.M {
+	return bson.M{"_id": groupId, "sessionActive": true, "activeSessionId": bson.M{"$exists": false}}
+}
+
+// adoptLegacySession membuatkan dokumen sessions untuk sesi lama grup agar persetujuan,
+// jeda, watchdog, dan arsip berlaku seperti sesi biasa. Jika request lain sudah lebih dulu
+// membuatkannya, ID sesi tersebut yang dikembalikan.
+func adoptLegacySession(ctx context.Context, groupId primitive.ObjectID) (primitive.ObjectID, error) {
+	session := newSession(groupId, primitive.NilObjectID, models.SessionTriggerLegacy)
+	err := withTransaction(ctx, func(sc mongo.SessionContext) error {
+		res, err := groupCol.UpdateOne(sc, legacySessionFilter(groupId), bson.M{"$set": bson.M{"activeSessionId": session.ID}})
+		if err != nil {
+			return fmt.Errorf("claim legacy session: %w", err)
+		}
+		if res.MatchedCount == 0 {
+			return errSessionNotActive
+		}
+		_, err = sessionCol.InsertOne(sc, session)
+		return err
+	})
+	if err == errSessionNotActive {
+		var group struct {
+			ActiveSessionID primitive.ObjectID `bson:"activeSessionId"`
+		}
+		if err := groupCol.FindOne(ctx, bson.M{"_id": groupId, "sessionActive": true}).Decode(&group); err != nil || group.ActiveSessionID.IsZero() {
+			return primitive.NilObjectID, errSessionNotActive
+		}
+		return group.ActiveSessionID, nil
+	}
+	if err != nil {
+		return primitive.NilObjectID, err
+	}
+	fmt.Println("[SESSION] sesi lama dibuatkan dokumen:", session.ID.Hex(), "group:", groupId.Hex())
+	return session.ID, nil
+}
+
+// findActiveSession mengambil sesi grup yang sedang berjalan (aktif atau dijeda)
+func findActiveSession(ctx context.Context, groupId primitive.ObjectID) (models.Session, bool) {
+	var session models.Session
+	sessionId := activeSessionID(ctx, groupId)
+	if sessionId.IsZero() {
+		return session, false
+	}
+	err := sessionCol.FindOne(ctx, bson.M{
+		"_id":    sessionId,
+		"status": bson.M{"$in": []string{models.SessionStatusActive, models.SessionStatusPaused}},
+	}).Decode(&session)
+	return session, err == nil
+}
+
+// trackSessionParticipant mencatat user sebagai peserta sesi.
+// Jika active true (kamera aktif / deteksi masuk), waktu aktivitas terakhir sesi ikut diperbarui.
+func trackSessionParticipant(ctx context.Context, sessionId, userId primitive.ObjectID, active bool) {
+	if sessionId.IsZero() {
+		return
+	}
+	update := bson.M{"$addToSet": bson.M{"participants": userId}}
+	if active {
+		update["$set"] = bson.M{"lastActivityAt": time.Now()}
+	}
+	_, err := sessionCol.UpdateOne(ctx, bson.M{"_id": sessionId}, update)
+	if err != nil {
+		fmt.Println("[SESSION] gagal tambah peserta:", err)
+	}
+}
+
+// POST /api/groups/:groupId/end-session
+func EndSession(c *fiber.Ctx) error {
+	fmt.Println("[END-SESSION] groupId:", c.Params("groupId"))
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
+	group, status, msg := loadManagedGroup(ctx, c, "groupId")
+	if status != 0 {
+		return c.Status(status).JSON(fiber.Map{"success": false, "message": msg})
+	}
 
-	// Hapus semua status kamera user di grup ini (disconnect semua user dari sesi)
-	res1, err := db.Collection("camera_status").DeleteMany(ctx, bson.M{"groupId": objGroupId})
-	fmt.Println("[END-SESSION] camera_status deleted:", res1.DeletedCount, "error:", err)
+	sessionId, err := endGroupSession(ctx, group.ID, models.SessionEndManual)
+	if err == errSessionNotActive {
+		return c.Status(409).JSON(fiber.Map{"success": false, "message": "Session is not active"})
+	}
 	if err != nil {
 		return c.Status(500).JSON(fiber.Map{"success": false, "message": "Failed to end session", "error": err.Error()})
 	}
+	var session models.Session
+	_ = sessionCol.FindOne(ctx, bson.M{"_id": sessionId}).Decode(&session)
+	return c.JSON(fiber.Map{
+		"success":   true,
+		"message":   "Sesi grup berhasil diakhiri. Semua user disconnect.",
+		"sessionId": sessionId,
+		"archive":   session.Archive,
+	})
+}
+
+// errSessionNotActive dikembalikan endGroupSession jika grup tidak punya sesi aktif
+var errSessionNotActive = errors.New("session is not active")
+
+// errSessionAlreadyActive dikembalikan startGroupSession jika grup sudah diklaim sesi lain
+var errSessionAlreadyActive = errors.New("session is already active")
 
-	// Set sessionActive=false pada group
-	res2, err := db.Collection("groups").UpdateOne(ctx, bson.M{"_id": objGroupId}, bson.M{"$set": bson.M{"sessionActive": false}})
-	fmt.Println("[END-SESSION] group update matched:", res2.MatchedCount, "modified:", res2.ModifiedCount, "error:", err)
+// endGroupSession mengakhiri sesi grup dalam satu transaksi: disconnect semua user,
+// menonaktifkan sesi grup, dan menandai sesi selesai dengan status arsip pending.
+// Setelah itu deteksi diarsipkan oleh archiveSession; jika gagal, worker arsip mencoba ulang
+// dan deteksi aktif tidak dihapus sebelum arsipnya tersimpan.
+// Dipakai oleh handler EndSession, scheduler, dan watchdog sesi.
+func endGroupSession(ctx context.Context, objGroupId primitive.ObjectID, reason string) (primitive.ObjectID, error) {
+	return endGroupSessionIf(ctx, objGroupId, primitive.NilObjectID, reason)
+}
+
+// endGroupSessionIf sama dengan endGroupSession, tetapi jika expected diisi sesi hanya diakhiri
+// selama expected masih sesi aktif grup. Dipakai scheduler dan watchdog agar tidak mengakhiri
+// sesi lain yang dimulai setelahnya.
+func endGroupSessionIf(ctx context.Context, objGroupId, expected primitive.ObjectID, reason string) (primitive.ObjectID, error) {
+	// Grup yang sudah dihapus tidak punya sesi aktif (sesinya diakhiri oleh DeleteGroup)
+	group, err := findGroup(ctx, objGroupId)
+	if err == mongo.ErrNoDocuments {
+		return primitive.NilObjectID, errSessionNotActive
+	}
 	if err != nil {
-		return c.Status(500).JSON(fiber.Map{"success": false, "message": "Failed to update group session status", "error": err.Error()})
+		return primitive.NilObjectID, fmt.Errorf("fetch group: %w", err)
+	}
+	if !group.SessionActive {
+		return primitive.NilObjectID, errSessionNotActive
+	}
+	if !expected.IsZero() && group.ActiveSessionID != expected {
+		return primitive.NilObjectID, errSessionNotActive
+	}
+	var session models.Session
+	if !group.ActiveSessionID.IsZero() {
+		if err := sessionCol.FindOne(ctx, bson.M{"_id": group.ActiveSessionID}).Decode(&session); err != nil {
+			return primitive.NilObjectID, fmt.Errorf("fetch session: %w", err)
+		}
+	}
+	endedAt := time.Now()
+	archive := models.SessionArchive{
+		Status: models.ArchiveStatusPending,
+		// Beri waktu archiveSession di bawah selesai sebelum worker ikut mencoba
+		NextAttemptAt: endedAt.Add(time.Minute),
 	}
 
-	// --- ARSIPKAN DETEKSI EMOSI SAAT END SESSION ---
-	// Ambil semua deteksi emosi aktif untuk grup ini
-	detectionsCursor, err := db.Collection("detections").Find(ctx, bson.M{"groupId": objGroupId})
-	if err != nil {
-		fmt.Println("[END-SESSION] gagal ambil deteksi:", err)
-	} else {
-		var detections []bson.M
-		err = detectionsCursor.All(ctx, &detections)
-		if err == nil && len(detections) > 0 {
-			// Simpan ke koleksi detection_history
-			historyDoc := bson.M{
-				"groupId":    objGroupId,
-				"sessionId":  primitive.NewObjectID(),
-				"detections": detections,
-				"endedAt":    time.Now(),
-			}
-			_, err = db.Collection("detection_history").InsertOne(ctx, historyDoc)
-			if err != nil {
-				fmt.Println("[END-SESSION] gagal simpan ke detection_history:", err)
-			}
+	sessionId := session.ID
+	err = withTransaction(ctx, func(sc mongo.SessionContext) error {
+		// Hapus semua status kamera user di grup ini (disconnect semua user dari sesi)
+		if _, err := cameraStatusCol.DeleteMany(sc, bson.M{"groupId": objGroupId}); err != nil {
+			return fmt.Errorf("delete camera status: %w", err)
+		}
+		// Set sessionActive=false pada group, hanya jika sesi aktifnya belum berganti sejak dibaca
+		groupFilter := bson.M{"_id": objGroupId, "sessionActive": true}
+		if !group.ActiveSessionID.IsZero() {
+			groupFilter["activeSessionId"] = group.ActiveSessionID
 		}
+		res, err := groupCol.UpdateOne(sc, groupFilter, bson.M{
+			"$set":   bson.M{"sessionActive": false},
+			"$unset": bson.M{"activeSessionId": ""},
+		})
+		if err != nil {
+			return fmt.Errorf("update group session status: %w", err)
+		}
+		if res.MatchedCount == 0 {
+			return errSessionNotActive
+		}
+		if session.ID.IsZero() {
+			// Sesi lama (sebelum ada koleksi sessions) dibuatkan dokumen agar bisa diarsipkan
+			legacy := newSession(objGroupId, primitive.NilObjectID, models.SessionTriggerLegacy)
+			legacy.StartedAt = group.CreatedAt
+			legacy.Status = models.SessionStatusEnded
+			legacy.EndedAt = &endedAt
+			legacy.EndReason = reason
+			legacy.Archive = &archive
+			sessionId = legacy.ID
+			_, err := sessionCol.InsertOne(sc, legacy)
+			return err
+		}
+		set := bson.M{
+			"status":    models.SessionStatusEnded,
+			"endedAt":   endedAt,
+			"endReason": reason,
+			"archive":   archive,
+		}
+		// Jeda yang masih terbuka ikut ditutup saat sesi diakhiri
+		if i := openPauseIndex(session); i >= 0 {
+			set[fmt.Sprintf("pauses.%d.endedAt", i)] = endedAt
+		}
+		// PIN join tidak berlaku lagi setelah sesi berakhir
+		update := bson.M{"$set": set, "$unset": bson.M{"joinPin": ""}}
+		if _, err := sessionCol.UpdateOne(sc, bson.M{"_id": session.ID}, update); err != nil {
+			return fmt.Errorf("update session status: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return primitive.NilObjectID, err
 	}
-	// Hapus deteksi emosi aktif dari koleksi utama
-	_, _ = db.Collection("detections").DeleteMany(ctx, bson.M{"groupId": objGroupId})
+	fmt.Println("[END-SESSION] sesi diakhiri:", sessionId.Hex(), "reason:", reason)
+	// Jadwal yang memulai sesi ini tidak perlu lagi mengakhirinya
+	scheduleCol.UpdateMany(ctx, bson.M{"runningSessionId": sessionId}, bson.M{"$unset": bson.M{"runningUntil": "", "runningSessionId": ""}})
 
-	return c.JSON(fiber.Map{"success": true, "message": "Sesi grup berhasil diakhiri. Semua user disconnect."})
+	// --- ARSIPKAN DETEKSI EMOSI SAAT END SESSION ---
+	// Context sendiri agar arsip tidak terpotong timeout handler pemanggil
+	archiveCtx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	if err := archiveSession(archiveCtx, sessionId); err != nil {
+		fmt.Println("[END-SESSION] arsip gagal, akan dicoba ulang:", err)
+	}
+	emitWebhookEvent(ctx, objGroupId, models.WebhookEventSessionEnded, fiber.Map{
+		"sessionId": sessionId.Hex(),
+		"reason":    reason,
+		"endedAt":   endedAt,
+	})
+	return sessionId, nil
 }
 
 // POST /api/groups/:groupId/start-session
+// PIN join tidak ikut dikembalikan; leader mengambilnya lewat GET /api/groups/:groupId/session/pin
 func StartSession(c *fiber.Ctx) error {
-	groupId := c.Params("groupId")
-	fmt.Println("[START-SESSION] groupId:", groupId)
-	if groupId == "" {
-		return c.Status(400).JSON(fiber.Map{"success": false, "message": "Group ID required"})
+	fmt.Println("[START-SESSION] groupId:", c.Params("groupId"))
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	group, status, msg := loadManagedGroup(ctx, c, "groupId")
+	if status != 0 {
+		return c.Status(status).JSON(fiber.Map{"success": false, "message": msg})
 	}
 
-	objGroupId, err := primitive.ObjectIDFromHex(groupId)
+	startedBy, _ := primitive.ObjectIDFromHex(c.Locals("userId").(string))
+	session, err := startGroupSession(ctx, group.ID, startedBy, models.SessionTriggerManual)
 	if err != nil {
-		return c.Status(400).JSON(fiber.Map{"success": false, "message": "Invalid groupId"})
+		return c.Status(500).JSON(fiber.Map{"success": false, "message": "Failed to start session", "error": err.Error()})
 	}
+	return c.JSON(fiber.Map{"success": true, "message": "Sesi baru berhasil dimulai.", "session": session})
+}
 
+// startGroupSession membuat sesi baru, mengaktifkan sesi grup, dan menginisialisasi status kamera semua anggota.
+// Jika grup sudah punya sesi aktif, sesi tersebut dikembalikan tanpa membuat sesi baru.
+// Dipakai oleh handler StartSession dan scheduler.
+func startGroupSession(ctx context.Context, objGroupId, startedBy primitive.ObjectID, trigger string) (models.Session, error) {
 	db := config.GetDB()
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-	defer cancel()
 
-	// Set sessionActive=true pada group
-	res, err := db.Collection("groups").UpdateOne(ctx, bson.M{"_id": objGroupId}, bson.M{"$set": bson.M{"sessionActive": true}})
-	fmt.Println("[START-SESSION] group update matched:", res.MatchedCount, "modified:", res.ModifiedCount, "error:", err)
+	group, err := findGroup(ctx, objGroupId)
 	if err != nil {
-		return c.Status(500).JSON(fiber.Map{"success": false, "message": "Failed to update group sessionActive", "error": err.Error()})
+		return models.Session{}, fmt.Errorf("fetch group: %w", err)
+	}
+	// Sesi lama tanpa dokumen sessions diakhiri (dan diarsipkan) dulu agar deteksinya tidak tercampur sesi baru
+	if group.SessionActive && group.ActiveSessionID.IsZero() {
+		if _, err := endGroupSession(ctx, objGroupId, models.SessionEndReplaced); err != nil && err != errSessionNotActive {
+			return models.Session{}, fmt.Errorf("end legacy session: %w", err)
+		}
+	}
+	// Grup diklaim secara atomik: hanya grup tanpa sesi berjalan (atau yang dokumen sesi aktifnya sudah
+	// tidak berjalan) yang bisa memulai sesi, sehingga dua start bersamaan tidak membuat dua sesi
+	claim := bson.M{"_id": objGroupId, "deletedAt": nil, "sessionActive": bson.M{"$ne": true}}
+	if group.SessionActive && !group.ActiveSessionID.IsZero() {
+		var existing models.Session
+		err = sessionCol.FindOne(ctx, bson.M{
+			"_id":    group.ActiveSessionID,
+			"status": bson.M{"$in": []string{models.SessionStatusActive, models.SessionStatusPaused}},
+		}).Decode(&existing)
+		if err == nil {
+			fmt.Println("[START-SESSION] sesi sudah aktif:", existing.ID.Hex())
+			return existing, nil
+		}
+		claim = bson.M{"_id": objGroupId, "deletedAt": nil, "activeSessionId": group.ActiveSessionID}
 	}
 
-	// Tambahkan log untuk memastikan update berhasil
-	var group models.Group
-	err = db.Collection("groups").FindOne(ctx, bson.M{"_id": objGroupId}).Decode(&group)
+	session := newSession(objGroupId, startedBy, trigger)
+	err = withTransaction(ctx, func(sc mongo.SessionContext) error {
+		// Set sessionActive=true pada group
+		res, err := groupCol.UpdateOne(sc, claim, bson.M{"$set": bson.M{
+			"sessionActive":   true,
+			"activeSessionId": session.ID,
+		}})
+		if err != nil {
+			return fmt.Errorf("update group sessionActive: %w", err)
+		}
+		if res.MatchedCount == 0 {
+			return errSessionAlreadyActive
+		}
+		if _, err := sessionCol.InsertOne(sc, session); err != nil {
+			return fmt.Errorf("create session: %w", err)
+		}
+		return nil
+	})
+	if err == errSessionAlreadyActive {
+		// Request lain lebih dulu memulai sesi; sesi tersebut yang dikembalikan
+		if existing, ok := findActiveSession(ctx, objGroupId); ok {
+			fmt.Println("[START-SESSION] sesi sudah aktif:", existing.ID.Hex())
+			return existing, nil
+		}
+		return models.Session{}, err
+	}
 	if err != nil {
-		return c.Status(500).JSON(fiber.Map{"success": false, "message": "Failed to fetch group after update", "error": err.Error()})
+		return models.Session{}, err
+	}
+	fmt.Println("[START-SESSION] sesi dimulai:", session.ID.Hex())
+
+	if pin, err := ensureJoinPIN(ctx, session); err == nil {
+		session.JoinPIN = pin
+	} else {
+		fmt.Println("[START-SESSION] gagal membuat PIN:", err)
+	}
+
+	// Anggota yang menunggu di ruang tunggu langsung masuk ke sesi
+	if admitted := admitLobby(ctx, objGroupId, session.ID); len(admitted) > 0 {
+		session.Participants = admitted
+		fmt.Println("[START-SESSION] anggota dari ruang tunggu:", len(admitted))
 	}
-	fmt.Println("[START-SESSION] sessionActive after update:", group.SessionActive)
 
 	// Ambil anggota grup
 	if len(group.Members) > 0 {
@@ -112,16 +366,116 @@ func StartSession(c *fiber.Ctx) error {
 		for _, userId := range group.Members {
 			db.Collection("camera_status").InsertOne(ctx, bson.M{
 				"groupId":   objGroupId,
+				"sessionId": session.ID,
 				"userId":    userId,
 				"isActive":  false,
 				"updatedAt": time.Now(),
 			})
 		}
 	}
+	emitWebhookEvent(ctx, objGroupId, models.WebhookEventSessionStarted, fiber.Map{
+		"sessionId": session.ID.Hex(),
+		"trigger":   trigger,
+		"startedBy": session.StartedBy,
+		"startedAt": session.StartedAt,
+	})
+	return session, nil
+}
+
+// GET /api/groups/:groupId/sessions?limit=20
+func GetGroupSessions(c *fiber.Ctx) error {
+	userId, ok := c.Locals("userId").(string)
+	if !ok {
+		return c.Status(401).JSON(fiber.Map{"success": false, "message": "Unauthorized"})
+	}
+	gid, err := primitive.ObjectIDFromHex(c.Params("groupId"))
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"success": false, "message": "Invalid groupId"})
+	}
+	limit := c.QueryInt("limit", 20)
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	group, err := findGroup(ctx, gid)
+	if err != nil {
+		return c.Status(404).JSON(fiber.Map{"success": false, "message": "Group not found"})
+	}
+	if !canViewGroup(ctx, group, userId) {
+		return c.Status(403).JSON(fiber.Map{"success": false, "message": "Not a member of this group"})
+	}
+	opts := options.Find().SetSort(bson.M{"startedAt": -1}).SetLimit(int64(limit))
+	cursor, err := sessionCol.Find(ctx, bson.M{"groupId": gid}, opts)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"success": false, "message": "Failed to fetch sessions"})
+	}
+	sessions := []models.Session{}
+	if err := cursor.All(ctx, &sessions); err != nil {
+		return c.Status(500).JSON(fiber.Map{"success": false, "message": "Failed to decode sessions"})
+	}
+	return c.JSON(fiber.Map{"success": true, "sessions": sessions})
+}
 
-	// (Opsional) Buat sesi baru di koleksi deteksi jika ingin tracking per sesi
-	//
-	// Bisa generate sessionId baru di sini jika ingin, lalu frontend kirim sessionId ke deteksi emosi
+// GET /api/sessions/:id
+// Detail sesi beserta arsip deteksinya (jika sesi sudah selesai).
+// Leader/admin organisasi melihat seluruh arsip, anggota biasa hanya deteksinya sendiri.
+func GetSession(c *fiber.Ctx) error {
+	userId, ok := c.Locals("userId").(string)
+	if !ok {
+		return c.Status(401).JSON(fiber.Map{"success": false, "message": "Unauthorized"})
+	}
+	uid, err := primitive.ObjectIDFromHex(userId)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"success": false, "message": "Invalid userId"})
+	}
+	sid, err := primitive.ObjectIDFromHex(c.Params("id"))
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"success": false, "message": "Invalid sessionId"})
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	var session models.Session
+	if err := sessionCol.FindOne(ctx, bson.M{"_id": sid}).Decode(&session); err != nil {
+		return c.Status(404).JSON(fiber.Map{"success": false, "message": "Session not found"})
+	}
+	group, err := findGroup(ctx, session.GroupID)
+	if err != nil {
+		return c.Status(404).JSON(fiber.Map{"success": false, "message": "Group not found"})
+	}
+	manager := canManageGroup(ctx, group, userId)
+	if !manager && !groupHasMember(group, uid) {
+		return c.Status(403).JSON(fiber.Map{"success": false, "message": "Not a member of this group"})
+	}
+	markers, err := findSessionMarkers(ctx, sid)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"success": false, "message": "Failed to fetch session markers"})
+	}
+	resp := fiber.Map{"success": true, "session": session, "markers": markers}
+	var history bson.M
+	err = config.GetDB().Collection("detection_history").FindOne(ctx, bson.M{"sessionId": sid}).Decode(&history)
+	if err == nil {
+		if !manager {
+			history = ownHistory(history, uid)
+		}
+		resp["history"] = history
+	} else if err != mongo.ErrNoDocuments {
+		return c.Status(500).JSON(fiber.Map{"success": false, "message": "Failed to fetch session history"})
+	}
+	return c.JSON(resp)
+}
 
-	return c.JSON(fiber.Map{"success": true, "message": "Sesi baru berhasil dimulai."})
+// ownHistory menyisakan deteksi milik user saja dari dokumen arsip sesi (tanpa ringkasan breakout room)
+func ownHistory(history bson.M, userId primitive.ObjectID) bson.M {
+	own := bson.A{}
+	if detections, ok := history["detections"].(bson.A); ok {
+		for _, d := range detections {
+			if doc, ok := d.(bson.M); ok && doc["userId"] == userId {
+				own = append(own, doc)
+			}
+		}
+	}
+	history["detections"] = own
+	delete(history, "rooms")
+	return history
 }
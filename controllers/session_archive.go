@@ -0,0 +1,212 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"sitor-backend/config"
+	"sitor-backend/models"
+
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var detectionHistoryCol = config.GetDB().Collection("detection_history")
+
+// archiveRetryDelay menghitung jeda sebelum percobaan arsip berikutnya (1, 2, 4, ... menit, maks 1 jam)
+func archiveRetryDelay(attempts int) time.Duration {
+	delay := time.Minute
+	for i := 1; i < attempts && delay < time.Hour; i++ {
+		delay *= 2
+	}
+	if delay > time.Hour {
+		delay = time.Hour
+	}
+	return delay
+}
+
+// archiveSession menyalin deteksi aktif sesi ke detection_history lalu menghapusnya, dalam satu transaksi.
+// Aman dipanggil berulang: arsip disimpan per sessionId (upsert) dan sesi yang sudah terarsip dilewati.
+func archiveSession(ctx context.Context, sessionId primitive.ObjectID) error {
+	var session models.Session
+	if err := sessionCol.FindOne(ctx, bson.M{"_id": sessionId}).Decode(&session); err != nil {
+		return fmt.Errorf("fetch session: %w", err)
+	}
+	if session.Archive != nil && session.Archive.Status == models.ArchiveStatusArchived {
+		return nil
+	}
+	err := withTransaction(ctx, func(sc mongo.SessionContext) error {
+		// Ringkas breakout room (jika ada) agar digabung ke arsip sesi induk
+		rooms, err := summarizeBreakoutRooms(sc, session.GroupID, session.ID)
+		if err != nil {
+			return fmt.Errorf("summarize breakout rooms: %w", err)
+		}
+		filter := sessionScopedFilter(session.GroupID, session.ID)
+		cursor, err := detectionCol.Find(sc, filter)
+		if err != nil {
+			return fmt.Errorf("fetch detections: %w", err)
+		}
+		var detections []bson.M
+		if err := cursor.All(sc, &detections); err != nil {
+			return fmt.Errorf("decode detections: %w", err)
+		}
+		if len(detections) > 0 {
+			endedAt := time.Now()
+			if session.EndedAt != nil {
+				endedAt = *session.EndedAt
+			}
+			historyDoc := bson.M{
+				"groupId":    session.GroupID,
+				"sessionId":  session.ID,
+				"detections": detections,
+				"startedAt":  session.StartedAt,
+				"endedAt":    endedAt,
+			}
+			if len(rooms) > 0 {
+				historyDoc["rooms"] = rooms
+			}
+			if len(session.Pauses) > 0 {
+				historyDoc["pauses"] = session.Pauses
+			}
+			_, err = detectionHistoryCol.ReplaceOne(sc, bson.M{"sessionId": session.ID}, historyDoc, options.Replace().SetUpsert(true))
+			if err != nil {
+				return fmt.Errorf("save detection history: %w", err)
+			}
+		}
+		// Deteksi aktif dan breakout room baru dihapus setelah arsip tersimpan
+		if _, err := detectionCol.DeleteMany(sc, filter); err != nil {
+			return fmt.Errorf("delete detections: %w", err)
+		}
+		if _, err := breakoutRoomCol.DeleteMany(sc, filter); err != nil {
+			return fmt.Errorf("delete breakout rooms: %w", err)
+		}
+		now := time.Now()
+		_, err = sessionCol.UpdateOne(sc, bson.M{"_id": session.ID}, bson.M{
+			"$set":   bson.M{"archive.status": models.ArchiveStatusArchived, "archive.archivedAt": now},
+			"$inc":   bson.M{"archive.attempts": 1},
+			"$unset": bson.M{"archive.lastError": ""},
+		})
+		return err
+	})
+	if err != nil {
+		attempts := 1
+		if session.Archive != nil {
+			attempts = session.Archive.Attempts + 1
+		}
+		_, uerr := sessionCol.UpdateOne(ctx, bson.M{"_id": session.ID}, bson.M{"$set": bson.M{
+			"archive.status":        models.ArchiveStatusFailed,
+			"archive.attempts":      attempts,
+			"archive.lastError":     err.Error(),
+			"archive.nextAttemptAt": time.Now().Add(archiveRetryDelay(attempts)),
+		}})
+		if uerr != nil {
+			log.Printf("[ARCHIVE] failed to record archive failure for session %s: %v", session.ID.Hex(), uerr)
+		}
+		return err
+	}
+	log.Printf("[ARCHIVE] session %s archived", session.ID.Hex())
+	return nil
+}
+
+// StartArchiveWorker mencoba ulang pengarsipan sesi yang pending atau gagal
+func StartArchiveWorker() {
+	runEvery("ARCHIVE", config.GetEnvDuration("ARCHIVE_RETRY_INTERVAL", time.Minute), retryPendingArchives)
+}
+
+func retryPendingArchives() {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
+	maxAttempts := config.GetEnvInt("ARCHIVE_MAX_ATTEMPTS", 10)
+	cursor, err := sessionCol.Find(ctx, bson.M{
+		"archive.status":        bson.M{"$in": []string{models.ArchiveStatusPending, models.ArchiveStatusFailed}},
+		"archive.nextAttemptAt": bson.M{"$lte": time.Now()},
+		"archive.attempts":      bson.M{"$lt": maxAttempts},
+	})
+	if err != nil {
+		log.Printf("[ARCHIVE] failed to fetch pending archives: %v", err)
+		return
+	}
+	var sessions []models.Session
+	if err := cursor.All(ctx, &sessions); err != nil {
+		log.Printf("[ARCHIVE] failed to decode pending archives: %v", err)
+		return
+	}
+	for _, s := range sessions {
+		if err := archiveSession(ctx, s.ID); err != nil {
+			log.Printf("[ARCHIVE] retry failed for session %s: %v", s.ID.Hex(), err)
+		}
+	}
+}
+
+// GET /api/sessions/:id/archive
+func GetSessionArchiveStatus(c *fiber.Ctx) error {
+	userId, ok := c.Locals("userId").(string)
+	if !ok {
+		return c.Status(401).JSON(fiber.Map{"success": false, "message": "Unauthorized"})
+	}
+	sid, err := primitive.ObjectIDFromHex(c.Params("id"))
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"success": false, "message": "Invalid sessionId"})
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	var session models.Session
+	if err := sessionCol.FindOne(ctx, bson.M{"_id": sid}).Decode(&session); err != nil {
+		return c.Status(404).JSON(fiber.Map{"success": false, "message": "Session not found"})
+	}
+	group, err := findGroup(ctx, session.GroupID)
+	if err != nil {
+		return c.Status(404).JSON(fiber.Map{"success": false, "message": "Group not found"})
+	}
+	if !canViewGroup(ctx, group, userId) {
+		return c.Status(403).JSON(fiber.Map{"success": false, "message": "Not a member of this group"})
+	}
+	return c.JSON(fiber.Map{"success": true, "sessionStatus": session.Status, "archive": session.Archive})
+}
+
+// POST /api/sessions/:id/archive/retry
+// Menjalankan ulang pengarsipan sekarang, termasuk jika batas percobaan worker sudah habis
+func RetrySessionArchive(c *fiber.Ctx) error {
+	userId := c.Locals("userId")
+	if userId == nil {
+		return c.Status(401).JSON(fiber.Map{"success": false, "message": "Unauthorized"})
+	}
+	sid, err := primitive.ObjectIDFromHex(c.Params("id"))
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"success": false, "message": "Invalid sessionId"})
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	var session models.Session
+	if err := sessionCol.FindOne(ctx, bson.M{"_id": sid}).Decode(&session); err != nil {
+		return c.Status(404).JSON(fiber.Map{"success": false, "message": "Session not found"})
+	}
+	var group models.Group
+	if err := groupCol.FindOne(ctx, bson.M{"_id": session.GroupID}).Decode(&group); err != nil {
+		return c.Status(404).JSON(fiber.Map{"success": false, "message": "Group not found"})
+	}
+	if !canManageGroup(ctx, group, userId.(string)) {
+		return c.Status(403).JSON(fiber.Map{"success": false, "message": "Only the group leader or an organization admin can retry archiving"})
+	}
+	if session.Archive == nil {
+		return c.Status(409).JSON(fiber.Map{"success": false, "message": "Session has not ended yet"})
+	}
+	if err := archiveSession(ctx, sid); err != nil {
+		return c.Status(500).JSON(fiber.Map{"success": false, "message": "Archiving failed", "error": err.Error()})
+	}
+	return c.JSON(fiber.Map{"success": true})
+}
+
+// ensureArchiveIndexes memastikan satu arsip per sesi sehingga upsert arsip idempoten
+func ensureArchiveIndexes(ctx context.Context) error {
+	_, err := detectionHistoryCol.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "sessionId", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	})
+	return err
+}
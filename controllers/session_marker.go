@@ -0,0 +1,132 @@
+package controllers
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"sitor-backend/config"
+	"sitor-backend/models"
+
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var sessionMarkerCol = config.GetDB().Collection("session_markers")
+
+const maxMarkerLabelLength = 100
+
+// POST /api/groups/:groupId/markers
+// Body: {"label": "quiz started", "note": "...", "at": "RFC3339 (opsional, default sekarang)"}
+func CreateSessionMarker(c *fiber.Ctx) error {
+	var body struct {
+		Label string     `json:"label"`
+		Note  string     `json:"note"`
+		At    *time.Time `json:"at"`
+	}
+	if err := c.BodyParser(&body); err != nil {
+		return c.Status(400).JSON(fiber.Map{"success": false, "message": "Invalid request"})
+	}
+	body.Label = strings.TrimSpace(body.Label)
+	if body.Label == "" || len(body.Label) > maxMarkerLabelLength {
+		return c.Status(400).JSON(fiber.Map{"success": false, "message": "Label is required (maximum 100 characters)"})
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	group, status, msg := loadManagedGroup(ctx, c, "groupId")
+	if status != 0 {
+		return c.Status(status).JSON(fiber.Map{"success": false, "message": msg})
+	}
+	session, ok := findActiveSession(ctx, group.ID)
+	if !ok {
+		return c.Status(409).JSON(fiber.Map{"success": false, "message": "Session is not active"})
+	}
+	now := time.Now()
+	at := now
+	if body.At != nil {
+		at = *body.At
+		if at.Before(session.StartedAt) || at.After(now.Add(time.Minute)) {
+			return c.Status(400).JSON(fiber.Map{"success": false, "message": "Marker time must be within the current session"})
+		}
+	}
+	createdBy, _ := primitive.ObjectIDFromHex(c.Locals("userId").(string))
+	marker := models.SessionMarker{
+		ID:        primitive.NewObjectID(),
+		SessionID: session.ID,
+		GroupID:   group.ID,
+		Label:     body.Label,
+		Note:      body.Note,
+		At:        at,
+		CreatedBy: createdBy,
+		CreatedAt: now,
+	}
+	if _, err := sessionMarkerCol.InsertOne(ctx, marker); err != nil {
+		return c.Status(500).JSON(fiber.Map{"success": false, "message": "Failed to create marker"})
+	}
+	return c.JSON(fiber.Map{"success": true, "marker": marker})
+}
+
+// DELETE /api/groups/:groupId/markers/:markerId
+func DeleteSessionMarker(c *fiber.Ctx) error {
+	mid, err := primitive.ObjectIDFromHex(c.Params("markerId"))
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"success": false, "message": "Invalid markerId"})
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	group, status, msg := loadManagedGroup(ctx, c, "groupId")
+	if status != 0 {
+		return c.Status(status).JSON(fiber.Map{"success": false, "message": msg})
+	}
+	res, err := sessionMarkerCol.DeleteOne(ctx, bson.M{"_id": mid, "groupId": group.ID})
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"success": false, "message": "Failed to delete marker"})
+	}
+	if res.DeletedCount == 0 {
+		return c.Status(404).JSON(fiber.Map{"success": false, "message": "Marker not found"})
+	}
+	return c.JSON(fiber.Map{"success": true})
+}
+
+// findSessionMarkers mengambil semua marker sesi terurut waktu
+func findSessionMarkers(ctx context.Context, sessionId primitive.ObjectID) ([]models.SessionMarker, error) {
+	cursor, err := sessionMarkerCol.Find(ctx, bson.M{"sessionId": sessionId}, options.Find().SetSort(bson.M{"at": 1}))
+	if err != nil {
+		return nil, err
+	}
+	markers := []models.SessionMarker{}
+	err = cursor.All(ctx, &markers)
+	return markers, err
+}
+
+// GET /api/sessions/:id/markers
+func GetSessionMarkers(c *fiber.Ctx) error {
+	userId, ok := c.Locals("userId").(string)
+	if !ok {
+		return c.Status(401).JSON(fiber.Map{"success": false, "message": "Unauthorized"})
+	}
+	sid, err := primitive.ObjectIDFromHex(c.Params("id"))
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"success": false, "message": "Invalid sessionId"})
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	var session models.Session
+	if err := sessionCol.FindOne(ctx, bson.M{"_id": sid}).Decode(&session); err != nil {
+		return c.Status(404).JSON(fiber.Map{"success": false, "message": "Session not found"})
+	}
+	group, err := findGroup(ctx, session.GroupID)
+	if err != nil {
+		return c.Status(404).JSON(fiber.Map{"success": false, "message": "Group not found"})
+	}
+	if !canViewGroup(ctx, group, userId) {
+		return c.Status(403).JSON(fiber.Map{"success": false, "message": "Not a member of this group"})
+	}
+	markers, err := findSessionMarkers(ctx, sid)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"success": false, "message": "Failed to fetch markers"})
+	}
+	return c.JSON(fiber.Map{"success": true, "markers": markers})
+}
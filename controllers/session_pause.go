@@ -0,0 +1,83 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"sitor-backend/models"
+
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// openPauseIndex mengembalikan indeks jeda yang belum ditutup, atau -1
+func openPauseIndex(session models.Session) int {
+	for i := len(session.Pauses) - 1; i >= 0; i-- {
+		if session.Pauses[i].EndedAt == nil {
+			return i
+		}
+	}
+	return -1
+}
+
+// POST /api/groups/:groupId/pause-session
+// Menjeda sesi: deteksi ditolak dan status kamera dibekukan sampai sesi dilanjutkan
+func PauseSession(c *fiber.Ctx) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	group, status, msg := loadManagedGroup(ctx, c, "groupId")
+	if status != 0 {
+		return c.Status(status).JSON(fiber.Map{"success": false, "message": msg})
+	}
+	session, ok := findActiveSession(ctx, group.ID)
+	if !ok {
+		return c.Status(409).JSON(fiber.Map{"success": false, "message": "Session is not active"})
+	}
+	if session.Status == models.SessionStatusPaused {
+		return c.Status(409).JSON(fiber.Map{"success": false, "message": "Session is already paused"})
+	}
+	pausedBy, _ := primitive.ObjectIDFromHex(c.Locals("userId").(string))
+	pause := models.SessionPause{StartedAt: time.Now(), PausedBy: pausedBy}
+	res, err := sessionCol.UpdateOne(ctx,
+		bson.M{"_id": session.ID, "status": models.SessionStatusActive},
+		bson.M{"$set": bson.M{"status": models.SessionStatusPaused}, "$push": bson.M{"pauses": pause}})
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"success": false, "message": "Failed to pause session"})
+	}
+	if res.ModifiedCount == 0 {
+		return c.Status(409).JSON(fiber.Map{"success": false, "message": "Session is already paused"})
+	}
+	return c.JSON(fiber.Map{"success": true, "message": "Sesi dijeda.", "pause": pause})
+}
+
+// POST /api/groups/:groupId/resume-session
+func ResumeSession(c *fiber.Ctx) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	group, status, msg := loadManagedGroup(ctx, c, "groupId")
+	if status != 0 {
+		return c.Status(status).JSON(fiber.Map{"success": false, "message": msg})
+	}
+	session, ok := findActiveSession(ctx, group.ID)
+	if !ok {
+		return c.Status(409).JSON(fiber.Map{"success": false, "message": "Session is not active"})
+	}
+	if session.Status != models.SessionStatusPaused {
+		return c.Status(409).JSON(fiber.Map{"success": false, "message": "Session is not paused"})
+	}
+	now := time.Now()
+	set := bson.M{"status": models.SessionStatusActive, "lastActivityAt": now}
+	if i := openPauseIndex(session); i >= 0 {
+		set[fmt.Sprintf("pauses.%d.endedAt", i)] = now
+	}
+	res, err := sessionCol.UpdateOne(ctx, bson.M{"_id": session.ID, "status": models.SessionStatusPaused}, bson.M{"$set": set})
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"success": false, "message": "Failed to resume session"})
+	}
+	if res.ModifiedCount == 0 {
+		return c.Status(409).JSON(fiber.Map{"success": false, "message": "Session is not paused"})
+	}
+	return c.JSON(fiber.Map{"success": true, "message": "Sesi dilanjutkan."})
+}
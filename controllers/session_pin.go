@@ -0,0 +1,147 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"sitor-backend/models"
+	"sitor-backend/utils"
+
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const joinPINDigits = 6
+
+// ensureJoinPIN memberi PIN pada sesi yang sedang berjalan jika belum punya.
+// Keunikan dijaga index unik joinPin; jika bentrok, PIN baru dibuat ulang.
+func ensureJoinPIN(ctx context.Context, session models.Session) (string, error) {
+	if session.JoinPIN != "" {
+		return session.JoinPIN, nil
+	}
+	for attempt := 0; attempt < 5; attempt++ {
+		pin, err := utils.GeneratePIN(joinPINDigits)
+		if err != nil {
+			return "", err
+		}
+		res, err := sessionCol.UpdateOne(ctx, bson.M{
+			"_id":     session.ID,
+			"status":  bson.M{"$in": []string{models.SessionStatusActive, models.SessionStatusPaused}},
+			"joinPin": bson.M{"$exists": false},
+		}, bson.M{"$set": bson.M{"joinPin": pin}})
+		if mongo.IsDuplicateKeyError(err) {
+			continue
+		}
+		if err != nil {
+			return "", err
+		}
+		if res.MatchedCount == 0 {
+			// Sesi sudah selesai atau PIN sudah diisi request lain
+			var current models.Session
+			if err := sessionCol.FindOne(ctx, bson.M{"_id": session.ID}).Decode(&current); err != nil {
+				return "", err
+			}
+			if current.JoinPIN == "" {
+				return "", errSessionNotActive
+			}
+			return current.JoinPIN, nil
+		}
+		return pin, nil
+	}
+	return "", errors.New("failed to generate unique join PIN")
+}
+
+// GET /api/groups/:groupId/session/pin
+// PIN sesi aktif untuk ditampilkan leader di kelas
+func GetSessionPIN(c *fiber.Ctx) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	group, status, msg := loadManagedGroup(ctx, c, "groupId")
+	if status != 0 {
+		return c.Status(status).JSON(fiber.Map{"success": false, "message": msg})
+	}
+	session, ok := findActiveSession(ctx, group.ID)
+	if !ok {
+		return c.Status(409).JSON(fiber.Map{"success": false, "message": "Session is not active"})
+	}
+	pin, err := ensureJoinPIN(ctx, session)
+	if err == errSessionNotActive {
+		return c.Status(409).JSON(fiber.Map{"success": false, "message": "Session is not active"})
+	}
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"success": false, "message": "Failed to generate join PIN"})
+	}
+	return c.JSON(fiber.Map{"success": true, "sessionId": session.ID, "pin": pin})
+}
+
+// POST /api/sessions/join-pin
+// Body: {"pin": "123456"}
+// Bergabung ke grup (jika belum anggota) dan langsung masuk ke sesi yang sedang berjalan
+func JoinSessionByPIN(c *fiber.Ctx) error {
+	userId := c.Locals("userId")
+	if userId == nil {
+		return c.Status(401).JSON(fiber.Map{"success": false, "message": "Unauthorized"})
+	}
+	uid, err := primitive.ObjectIDFromHex(userId.(string))
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"success": false, "message": "Invalid userId"})
+	}
+	var body struct {
+		PIN string `json:"pin"`
+	}
+	if err := c.BodyParser(&body); err != nil || len(body.PIN) != joinPINDigits {
+		return c.Status(400).JSON(fiber.Map{"success": false, "message": "PIN must be 6 digits"})
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	var session models.Session
+	err = sessionCol.FindOne(ctx, bson.M{
+		"joinPin": body.PIN,
+		"status":  bson.M{"$in": []string{models.SessionStatusActive, models.SessionStatusPaused}},
+	}).Decode(&session)
+	if err != nil {
+		return c.Status(404).JSON(fiber.Map{"success": false, "message": "Invalid or expired PIN"})
+	}
+	group, err := findGroup(ctx, session.GroupID)
+	if err != nil || group.ActiveSessionID != session.ID {
+		return c.Status(404).JSON(fiber.Map{"success": false, "message": "Invalid or expired PIN"})
+	}
+	if !groupHasMember(group, uid) {
+		if err := addGroupMember(ctx, group.ID, uid); err != nil {
+			return c.Status(500).JSON(fiber.Map{"success": false, "message": "Failed to join group"})
+		}
+		// Anggota baru belum punya status kamera dari saat sesi dimulai
+		_, err := cameraStatusCol.UpdateOne(ctx,
+			bson.M{"groupId": group.ID, "userId": uid},
+			bson.M{"$setOnInsert": bson.M{"sessionId": session.ID, "isActive": false, "updatedAt": time.Now()}},
+			options.Update().SetUpsert(true))
+		if err != nil {
+			fmt.Println("[JOIN-PIN] gagal inisialisasi status kamera:", err)
+		}
+	}
+	ensureJoined(ctx, session.ID, group.ID, uid)
+	trackSessionParticipant(ctx, session.ID, uid, false)
+	return c.JSON(fiber.Map{
+		"success":       true,
+		"groupId":       group.ID,
+		"groupName":     group.Name,
+		"sessionId":     session.ID,
+		"sessionStatus": session.Status,
+	})
+}
+
+// ensureSessionPINIndexes memastikan PIN unik di antara sesi yang sedang berjalan.
+// PIN dihapus saat sesi berakhir sehingga bisa dipakai ulang oleh sesi lain.
+func ensureSessionPINIndexes(ctx context.Context) error {
+	_, err := sessionCol.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "joinPin", Value: 1}},
+		Options: options.Index().SetUnique(true).
+			SetPartialFilterExpression(bson.M{"joinPin": bson.M{"$exists": true}}),
+	})
+	return err
+}
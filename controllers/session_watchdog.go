@@ -0,0 +1,163 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"sitor-backend/config"
+	"sitor-backend/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// StartSessionWatchdog mengakhiri sesi yang melewati durasi maksimum (SESSION_MAX_DURATION, termasuk jeda)
+// atau tidak ada kamera aktif selama SESSION_IDLE_TIMEOUT. Nilai 0 menonaktifkan pengecekan tersebut.
+func StartSessionWatchdog() {
+	runEvery("WATCHDOG", config.GetEnvDuration("SESSION_WATCHDOG_INTERVAL", time.Minute), checkSessionTimeouts)
+}
+
+func checkSessionTimeouts() {
+	maxDuration := config.GetEnvDuration("SESSION_MAX_DURATION", 4*time.Hour)
+	idleTimeout := config.GetEnvDuration("SESSION_IDLE_TIMEOUT", 30*time.Minute)
+	if maxDuration <= 0 && idleTimeout <= 0 {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	cursor, err := sessionCol.Find(ctx, bson.M{"status": bson.M{"$in": []string{models.SessionStatusActive, models.SessionStatusPaused}}})
+	if err != nil {
+		log.Printf("[WATCHDOG] failed to fetch active sessions: %v", err)
+		return
+	}
+	var sessions []models.Session
+	if err := cursor.All(ctx, &sessions); err != nil {
+		log.Printf("[WATCHDOG] failed to decode active sessions: %v", err)
+		return
+	}
+	now := time.Now()
+	for _, s := range sessions {
+		reason := sessionTimeoutReason(ctx, s, now, maxDuration, idleTimeout)
+		if reason == "" {
+			continue
+		}
+		// Hanya akhiri sesi grup jika dokumen ini memang sesi aktifnya; dokumen sisa sesi lama
+		// cukup ditandai selesai agar sesi grup yang lebih baru tidak ikut terhenti
+		_, err := endGroupSessionIf(ctx, s.GroupID, s.ID, reason)
+		if err == errSessionNotActive {
+			if err := closeStaleSession(ctx, s, reason); err != nil {
+				log.Printf("[WATCHDOG] failed to close stale session %s: %v", s.ID.Hex(), err)
+			} else {
+				log.Printf("[WATCHDOG] stale session %s of group %s closed (%s)", s.ID.Hex(), s.GroupID.Hex(), reason)
+			}
+			continue
+		}
+		if err != nil {
+			log.Printf("[WATCHDOG] failed to end session %s: %v", s.ID.Hex(), err)
+			continue
+		}
+		log.Printf("[WATCHDOG] session %s of group %s ended (%s)", s.ID.Hex(), s.GroupID.Hex(), reason)
+		notifySessionTimeout(ctx, s, reason)
+	}
+	checkLegacySessionTimeouts(ctx, now, maxDuration, idleTimeout)
+}
+
+// checkLegacySessionTimeouts mengakhiri sesi lama (grup sessionActive tanpa dokumen sessions)
+// dengan aturan yang sama, dihitung sejak grup dibuat. endGroupSession membuatkan dokumen sesinya.
+func checkLegacySessionTimeouts(ctx context.Context, now time.Time, maxDuration, idleTimeout time.Duration) {
+	cursor, err := groupCol.Find(ctx, bson.M{
+		"sessionActive":   true,
+		"activeSessionId": bson.M{"$exists": false},
+		"deletedAt":       nil,
+	})
+	if err != nil {
+		log.Printf("[WATCHDOG] failed to fetch legacy sessions: %v", err)
+		return
+	}
+	var groups []models.Group
+	if err := cursor.All(ctx, &groups); err != nil {
+		log.Printf("[WATCHDOG] failed to decode legacy sessions: %v", err)
+		return
+	}
+	for _, g := range groups {
+		legacy := models.Session{GroupID: g.ID, StartedAt: g.CreatedAt, Status: models.SessionStatusActive}
+		reason := sessionTimeoutReason(ctx, legacy, now, maxDuration, idleTimeout)
+		if reason == "" {
+			continue
+		}
+		sessionId, err := endGroupSession(ctx, g.ID, reason)
+		if err == errSessionNotActive {
+			continue
+		}
+		if err != nil {
+			log.Printf("[WATCHDOG] failed to end legacy session of group %s: %v", g.ID.Hex(), err)
+			continue
+		}
+		log.Printf("[WATCHDOG] legacy session of group %s ended as %s (%s)", g.ID.Hex(), sessionId.Hex(), reason)
+		legacy.ID = sessionId
+		notifySessionTimeout(ctx, legacy, reason)
+	}
+}
+
+// sessionTimeoutReason mengembalikan alasan sesi diakhiri watchdog, atau "" jika sesi masih boleh berjalan.
+// Sesi yang dijeda tidak dianggap idle, tapi tetap dibatasi durasi maksimum.
+func sessionTimeoutReason(ctx context.Context, s models.Session, now time.Time, maxDuration, idleTimeout time.Duration) string {
+	if maxDuration > 0 && now.Sub(s.StartedAt) >= maxDuration {
+		return models.SessionEndMaxDuration
+	}
+	if idleTimeout > 0 && s.Status == models.SessionStatusActive && sessionIdleSince(ctx, s).Before(now.Add(-idleTimeout)) {
+		return models.SessionEndIdle
+	}
+	return ""
+}
+
+// closeStaleSession menandai dokumen sesi yang bukan lagi sesi aktif grup sebagai selesai,
+// dengan arsip pending agar deteksinya tetap diarsipkan oleh worker arsip
+func closeStaleSession(ctx context.Context, s models.Session, reason string) error {
+	now := time.Now()
+	_, err := sessionCol.UpdateOne(ctx,
+		bson.M{"_id": s.ID, "status": bson.M{"$in": []string{models.SessionStatusActive, models.SessionStatusPaused}}},
+		bson.M{
+			"$set": bson.M{
+				"status":    models.SessionStatusEnded,
+				"endedAt":   now,
+				"endReason": reason,
+				"archive":   models.SessionArchive{Status: models.ArchiveStatusPending, NextAttemptAt: now},
+			},
+			"$unset": bson.M{"joinPin": ""},
+		})
+	return err
+}
+
+// sessionIdleSince mengembalikan sejak kapan sesi dianggap idle.
+// Jika masih ada kamera aktif, sesi tidak idle (mengembalikan waktu sekarang).
+func sessionIdleSince(ctx context.Context, s models.Session) time.Time {
+	count, err := cameraStatusCol.CountDocuments(ctx, bson.M{"groupId": s.GroupID, "isActive": true})
+	if err != nil || count > 0 {
+		return time.Now()
+	}
+	if s.LastActivityAt.After(s.StartedAt) {
+		return s.LastActivityAt
+	}
+	return s.StartedAt
+}
+
+// notifySessionTimeout memberi tahu leader grup bahwa sesinya diakhiri otomatis
+func notifySessionTimeout(ctx context.Context, s models.Session, reason string) {
+	var group models.Group
+	if err := groupCol.FindOne(ctx, bson.M{"_id": s.GroupID}).Decode(&group); err != nil {
+		return
+	}
+	message := fmt.Sprintf("Sesi grup %s diakhiri otomatis karena melewati durasi maksimum.", group.Name)
+	if reason == models.SessionEndIdle {
+		message = fmt.Sprintf("Sesi grup %s diakhiri otomatis karena tidak ada kamera aktif.", group.Name)
+	}
+	notifyUser(ctx, models.Notification{
+		UserID:    group.LeaderID,
+		GroupID:   group.ID,
+		SessionID: s.ID,
+		Type:      "session.auto_ended",
+		Message:   message,
+	})
+}
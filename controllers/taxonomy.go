@@ -0,0 +1,13 @@
+package controllers
+
+import (
+	"sitor-backend/taxonomy"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// GET /api/taxonomy
+// Label emosi yang diterima server, dipakai client untuk memetakan output detektornya
+func GetEmotionTaxonomy(c *fiber.Ctx) error {
+	return c.JSON(fiber.Map{"success": true, "taxonomy": taxonomy.Current()})
+}
@@ -0,0 +1,307 @@
+package controllers
+
+import (
+	"context"
+	"net/url"
+	"time"
+
+	"sitor-backend/config"
+	"sitor-backend/models"
+	"sitor-backend/utils"
+
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var webhookCol = config.GetDB().Collection("webhooks")
+
+// knownWebhookEvents adalah event yang boleh dilanggani
+var knownWebhookEvents = map[string]bool{
+	models.WebhookEventSessionStarted: true,
+	models.WebhookEventSessionEnded:   true,
+	models.WebhookEventMemberJoined:   true,
+	models.WebhookEventAlertTriggered: true,
+}
+
+// webhookBody adalah body create/update webhook
+type webhookBody struct {
+	URL    string   `json:"url"`
+	Events []string `json:"events"`
+	Active *bool    `json:"active"`
+}
+
+// validate memeriksa URL dan daftar event, sekaligus membuang event duplikat
+func (b *webhookBody) validate() string {
+	u, err := url.Parse(b.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Hostname() == "" {
+		return "url must be a valid http(s) URL"
+	}
+	if err := checkWebhookHost(u.Hostname()); err != nil {
+		return err.Error()
+	}
+	if len(b.Events) == 0 {
+		return "At least one event is required"
+	}
+	seen := map[string]bool{}
+	events := []string{}
+	for _, e := range b.Events {
+		if !knownWebhookEvents[e] {
+			return "Unknown event: " + e
+		}
+		if !seen[e] {
+			seen[e] = true
+			events = append(events, e)
+		}
+	}
+	b.Events = events
+	return ""
+}
+
+// createWebhook menyimpan webhook baru milik grup atau organisasi.
+// Secret hanya dikembalikan sekali di respons ini.
+func createWebhook(ctx context.Context, c *fiber.Ctx, hook models.Webhook) error {
+	var body webhookBody
+	if err := c.BodyParser(&body); err != nil {
+		return c.Status(400).JSON(fiber.Map{"success": false, "message": "Invalid request"})
+	}
+	if msg := body.validate(); msg != "" {
+		return c.Status(400).JSON(fiber.Map{"success": false, "message": msg})
+	}
+	secret, err := utils.GenerateSecret(32)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"success": false, "message": "Failed to generate secret"})
+	}
+	hook.ID = primitive.NewObjectID()
+	hook.URL = body.URL
+	hook.Events = body.Events
+	hook.Secret = secret
+	hook.Active = body.Active == nil || *body.Active
+	hook.CreatedBy, _ = primitive.ObjectIDFromHex(c.Locals("userId").(string))
+	hook.CreatedAt = time.Now()
+	if _, err := webhookCol.InsertOne(ctx, hook); err != nil {
+		return c.Status(500).JSON(fiber.Map{"success": false, "message": "Failed to create webhook"})
+	}
+	return c.Status(201).JSON(fiber.Map{"success": true, "webhook": hook, "secret": secret})
+}
+
+// listWebhooks mengembalikan webhook sesuai filter pemilik
+func listWebhooks(ctx context.Context, c *fiber.Ctx, filter bson.M) error {
+	cursor, err := webhookCol.Find(ctx, filter, options.Find().SetSort(bson.M{"createdAt": 1}))
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"success": false, "message": "Failed to fetch webhooks"})
+	}
+	hooks := []models.Webhook{}
+	if err := cursor.All(ctx, &hooks); err != nil {
+		return c.Status(500).JSON(fiber.Map{"success": false, "message": "Failed to decode webhooks"})
+	}
+	return c.JSON(fiber.Map{"success": true, "webhooks": hooks})
+}
+
+// POST /api/groups/:groupId/webhooks
+func CreateGroupWebhook(c *fiber.Ctx) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	group, status, msg := loadManagedGroup(ctx, c, "groupId")
+	if status != 0 {
+		return c.Status(status).JSON(fiber.Map{"success": false, "message": msg})
+	}
+	return createWebhook(ctx, c, models.Webhook{GroupID: group.ID})
+}
+
+// GET /api/groups/:groupId/webhooks
+func ListGroupWebhooks(c *fiber.Ctx) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	group, status, msg := loadManagedGroup(ctx, c, "groupId")
+	if status != 0 {
+		return c.Status(status).JSON(fiber.Map{"success": false, "message": msg})
+	}
+	return listWebhooks(ctx, c, bson.M{"groupId": group.ID})
+}
+
+// POST /api/organizations/:id/webhooks
+// Webhook organisasi menerima event dari semua grup milik organisasi
+func CreateOrganizationWebhook(c *fiber.Ctx) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	org, status, msg := loadOrganization(ctx, c, true)
+	if status != 0 {
+		return c.Status(status).JSON(fiber.Map{"success": false, "message": msg})
+	}
+	return createWebhook(ctx, c, models.Webhook{OrganizationID: org.ID})
+}
+
+// GET /api/organizations/:id/webhooks
+func ListOrganizationWebhooks(c *fiber.Ctx) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	org, status, msg := loadOrganization(ctx, c, true)
+	if status != 0 {
+		return c.Status(status).JSON(fiber.Map{"success": false, "message": msg})
+	}
+	return listWebhooks(ctx, c, bson.M{"organizationId": org.ID})
+}
+
+// loadManagedWebhook mengambil webhook dari parameter :webhookId dan memastikan user boleh mengelolanya:
+// leader/admin organisasi untuk webhook grup, admin organisasi untuk webhook organisasi
+func loadManagedWebhook(ctx context.Context, c *fiber.Ctx) (models.Webhook, int, string) {
+	var hook models.Webhook
+	userId := c.Locals("userId")
+	if userId == nil {
+		return hook, 401, "Unauthorized"
+	}
+	uid, err := primitive.ObjectIDFromHex(userId.(string))
+	if err != nil {
+		return hook, 400, "Invalid userId"
+	}
+	id, err := primitive.ObjectIDFromHex(c.Params("webhookId"))
+	if err != nil {
+		return hook, 400, "Invalid webhookId"
+	}
+	if err := webhookCol.FindOne(ctx, bson.M{"_id": id}).Decode(&hook); err != nil {
+		return hook, 404, "Webhook not found"
+	}
+	if !hook.GroupID.IsZero() {
+		group, err := findGroup(ctx, hook.GroupID)
+		if err != nil {
+			return hook, 404, "Webhook not found"
+		}
+		if !canManageGroup(ctx, group, userId.(string)) {
+			return hook, 403, "Only the group leader or an organization admin can manage this webhook"
+		}
+		return hook, 0, ""
+	}
+	count, err := organizationCol.CountDocuments(ctx, bson.M{"_id": hook.OrganizationID, "admins": uid})
+	if err != nil || count == 0 {
+		return hook, 403, "Only organization admins can manage this webhook"
+	}
+	return hook, 0, ""
+}
+
+// PUT /api/webhooks/:webhookId
+func UpdateWebhook(c *fiber.Ctx) error {
+	var body webhookBody
+	if err := c.BodyParser(&body); err != nil {
+		return c.Status(400).JSON(fiber.Map{"success": false, "message": "Invalid request"})
+	}
+	if msg := body.validate(); msg != "" {
+		return c.Status(400).JSON(fiber.Map{"success": false, "message": msg})
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	hook, status, msg := loadManagedWebhook(ctx, c)
+	if status != 0 {
+		return c.Status(status).JSON(fiber.Map{"success": false, "message": msg})
+	}
+	set := bson.M{"url": body.URL, "events": body.Events}
+	if body.Active != nil {
+		set["active"] = *body.Active
+	}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+	if err := webhookCol.FindOneAndUpdate(ctx, bson.M{"_id": hook.ID}, bson.M{"$set": set}, opts).Decode(&hook); err != nil {
+		return c.Status(500).JSON(fiber.Map{"success": false, "message": "Failed to update webhook"})
+	}
+	return c.JSON(fiber.Map{"success": true, "webhook": hook})
+}
+
+// POST /api/webhooks/:webhookId/rotate-secret
+func RotateWebhookSecret(c *fiber.Ctx) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	hook, status, msg := loadManagedWebhook(ctx, c)
+	if status != 0 {
+		return c.Status(status).JSON(fiber.Map{"success": false, "message": msg})
+	}
+	secret, err := utils.GenerateSecret(32)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"success": false, "message": "Failed to generate secret"})
+	}
+	if _, err := webhookCol.UpdateOne(ctx, bson.M{"_id": hook.ID}, bson.M{"$set": bson.M{"secret": secret}}); err != nil {
+		return c.Status(500).JSON(fiber.Map{"success": false, "message": "Failed to rotate secret"})
+	}
+	return c.JSON(fiber.Map{"success": true, "secret": secret})
+}
+
+// DELETE /api/webhooks/:webhookId
+// Log pengiriman webhook ikut dihapus
+func DeleteWebhook(c *fiber.Ctx) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	hook, status, msg := loadManagedWebhook(ctx, c)
+	if status != 0 {
+		return c.Status(status).JSON(fiber.Map{"success": false, "message": msg})
+	}
+	err := withTransaction(ctx, func(sc mongo.SessionContext) error {
+		if _, err := webhookDeliveryCol.DeleteMany(sc, bson.M{"webhookId": hook.ID}); err != nil {
+			return err
+		}
+		_, err := webhookCol.DeleteOne(sc, bson.M{"_id": hook.ID})
+		return err
+	})
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"success": false, "message": "Failed to delete webhook"})
+	}
+	return c.JSON(fiber.Map{"success": true})
+}
+
+// GET /api/webhooks/:webhookId/deliveries?status=failed&limit=50
+func GetWebhookDeliveries(c *fiber.Ctx) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	hook, status, msg := loadManagedWebhook(ctx, c)
+	if status != 0 {
+		return c.Status(status).JSON(fiber.Map{"success": false, "message": msg})
+	}
+	filter := bson.M{"webhookId": hook.ID}
+	if s := c.Query("status"); s != "" {
+		filter["status"] = s
+	}
+	limit := c.QueryInt("limit", 50)
+	if limit < 1 || limit > 200 {
+		limit = 50
+	}
+	opts := options.Find().SetSort(bson.M{"createdAt": -1}).SetLimit(int64(limit))
+	cursor, err := webhookDeliveryCol.Find(ctx, filter, opts)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"success": false, "message": "Failed to fetch deliveries"})
+	}
+	deliveries := []models.WebhookDelivery{}
+	if err := cursor.All(ctx, &deliveries); err != nil {
+		return c.Status(500).JSON(fiber.Map{"success": false, "message": "Failed to decode deliveries"})
+	}
+	return c.JSON(fiber.Map{"success": true, "deliveries": deliveries})
+}
+
+// POST /api/webhooks/:webhookId/test
+// Mengirim event webhook.test langsung dan mengembalikan hasil pengirimannya.
+// Jika gagal, pengiriman dicoba ulang oleh worker seperti event biasa.
+func TestWebhook(c *fiber.Ctx) error {
+	ctx, cancel := context.WithTimeout(context.Background(), webhookClient.Timeout+5*time.Second)
+	defer cancel()
+	hook, status, msg := loadManagedWebhook(ctx, c)
+	if status != 0 {
+		return c.Status(status).JSON(fiber.Map{"success": false, "message": msg})
+	}
+	if !hook.Active {
+		return c.Status(409).JSON(fiber.Map{"success": false, "message": "Webhook is disabled"})
+	}
+	delivery, err := newWebhookDelivery(hook, hook.GroupID, models.WebhookEventTest, fiber.Map{
+		"webhookId":   hook.ID.Hex(),
+		"message":     "Test delivery from SITOR",
+		"requestedBy": c.Locals("userId"),
+	})
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"success": false, "message": "Failed to build test payload"})
+	}
+	if _, err := webhookDeliveryCol.InsertOne(ctx, delivery); err != nil {
+		return c.Status(500).JSON(fiber.Map{"success": false, "message": "Failed to queue test delivery"})
+	}
+	delivery, err = attemptWebhookDelivery(ctx, delivery.ID)
+	if err != nil {
+		return c.Status(502).JSON(fiber.Map{"success": false, "message": "Test delivery failed", "error": err.Error(), "delivery": delivery})
+	}
+	return c.JSON(fiber.Map{"success": true, "delivery": delivery})
+}
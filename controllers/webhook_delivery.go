@@ -0,0 +1,300 @@
+package controllers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"strconv"
+	"syscall"
+	"time"
+
+	"sitor-backend/config"
+	"sitor-backend/models"
+	"sitor-backend/utils"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var webhookDeliveryCol = config.GetDB().Collection("webhook_deliveries")
+
+// webhookClient hanya boleh terhubung ke alamat publik. Pengecekan dilakukan lagi saat dial
+// (bukan hanya saat webhook disimpan) agar DNS rebinding tidak bisa mengarah ke jaringan internal.
+var webhookClient = &http.Client{
+	Timeout: config.GetEnvDuration("WEBHOOK_TIMEOUT", 10*time.Second),
+	Transport: &http.Transport{
+		DialContext:         (&net.Dialer{Timeout: 5 * time.Second, Control: webhookDialControl}).DialContext,
+		TLSHandshakeTimeout: 5 * time.Second,
+	},
+}
+
+// cgnatBlock adalah rentang shared address space (RFC 6598) yang juga tidak boleh dituju
+var _, cgnatBlock, _ = net.ParseCIDR("100.64.0.0/10")
+
+// webhookIPBlocked menolak loopback, jaringan privat, link-local (termasuk metadata cloud 169.254.169.254),
+// multicast, dan alamat unspecified. WEBHOOK_ALLOW_PRIVATE=true mematikan pengecekan untuk development.
+func webhookIPBlocked(ip net.IP) bool {
+	if config.GetEnv("WEBHOOK_ALLOW_PRIVATE", "") == "true" {
+		return false
+	}
+	return ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() ||
+		ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() || ip.IsInterfaceLocalMulticast() ||
+		ip.IsMulticast() || cgnatBlock.Contains(ip)
+}
+
+// checkWebhookHost me-resolve host URL webhook dan menolak jika salah satu alamatnya tidak publik
+func checkWebhookHost(host string) error {
+	if ip := net.ParseIP(host); ip != nil {
+		if webhookIPBlocked(ip) {
+			return errors.New("url must not point to a private or internal address")
+		}
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+	addrs, err := net.DefaultResolver.LookupIPAddr(ctx, host)
+	if err != nil || len(addrs) == 0 {
+		return errors.New("url host could not be resolved")
+	}
+	for _, addr := range addrs {
+		if webhookIPBlocked(addr.IP) {
+			return errors.New("url must not point to a private or internal address")
+		}
+	}
+	return nil
+}
+
+// webhookDialControl memeriksa alamat IP yang benar-benar akan di-dial
+func webhookDialControl(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	ip := net.ParseIP(host)
+	if ip == nil || webhookIPBlocked(ip) {
+		return fmt.Errorf("webhook address %s is not allowed", host)
+	}
+	return nil
+}
+
+// webhookRetryDelay menghitung jeda sebelum pengiriman ulang (30 detik, 1, 2, 4, ... menit, maks 1 jam)
+func webhookRetryDelay(attempts int) time.Duration {
+	delay := 30 * time.Second
+	for i := 1; i < attempts && delay < time.Hour; i++ {
+		delay *= 2
+	}
+	if delay > time.Hour {
+		delay = time.Hour
+	}
+	return delay
+}
+
+// emitWebhookEvent mencatat pengiriman event ke semua webhook aktif milik grup dan organisasinya,
+// lalu mengirimnya di latar belakang. Kegagalan hanya dicatat agar alur utama tidak terganggu.
+func emitWebhookEvent(ctx context.Context, groupId primitive.ObjectID, event string, data interface{}) {
+	group, err := findGroup(ctx, groupId)
+	if err != nil {
+		return
+	}
+	owners := bson.A{bson.M{"groupId": group.ID}}
+	if !group.OrganizationID.IsZero() {
+		owners = append(owners, bson.M{"organizationId": group.OrganizationID})
+	}
+	cursor, err := webhookCol.Find(ctx, bson.M{"active": true, "events": event, "$or": owners})
+	if err != nil {
+		log.Printf("[WEBHOOK] failed to fetch webhooks for group %s: %v", group.ID.Hex(), err)
+		return
+	}
+	var hooks []models.Webhook
+	if err := cursor.All(ctx, &hooks); err != nil || len(hooks) == 0 {
+		return
+	}
+	var ids []primitive.ObjectID
+	for _, hook := range hooks {
+		delivery, err := newWebhookDelivery(hook, group.ID, event, data)
+		if err != nil {
+			log.Printf("[WEBHOOK] failed to build %s payload: %v", event, err)
+			return
+		}
+		if _, err := webhookDeliveryCol.InsertOne(ctx, delivery); err != nil {
+			log.Printf("[WEBHOOK] failed to queue %s for webhook %s: %v", event, hook.ID.Hex(), err)
+			continue
+		}
+		ids = append(ids, delivery.ID)
+	}
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+		defer cancel()
+		for _, id := range ids {
+			if _, err := attemptWebhookDelivery(ctx, id); err != nil {
+				log.Printf("[WEBHOOK] delivery %s failed: %v", id.Hex(), err)
+			}
+		}
+	}()
+}
+
+// newWebhookDelivery menyusun payload event dan dokumen pengirimannya
+func newWebhookDelivery(hook models.Webhook, groupId primitive.ObjectID, event string, data interface{}) (models.WebhookDelivery, error) {
+	now := time.Now()
+	delivery := models.WebhookDelivery{
+		ID:            primitive.NewObjectID(),
+		WebhookID:     hook.ID,
+		GroupID:       groupId,
+		Event:         event,
+		Status:        models.WebhookDeliveryPending,
+		NextAttemptAt: now,
+		CreatedAt:     now,
+	}
+	payload := map[string]interface{}{
+		"id":        delivery.ID.Hex(),
+		"event":     event,
+		"createdAt": now,
+		"data":      data,
+	}
+	if !groupId.IsZero() {
+		payload["groupId"] = groupId.Hex()
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return delivery, err
+	}
+	delivery.Payload = string(body)
+	return delivery, nil
+}
+
+// attemptWebhookDelivery mengirim satu pengiriman yang jatuh tempo.
+// Pengiriman diklaim dulu dengan memundurkan nextAttemptAt agar tidak dikirim ganda oleh worker.
+func attemptWebhookDelivery(ctx context.Context, id primitive.ObjectID) (models.WebhookDelivery, error) {
+	var delivery models.WebhookDelivery
+	now := time.Now()
+	err := webhookDeliveryCol.FindOneAndUpdate(ctx,
+		bson.M{"_id": id, "status": models.WebhookDeliveryPending, "nextAttemptAt": bson.M{"$lte": now}},
+		bson.M{"$set": bson.M{"nextAttemptAt": now.Add(webhookClient.Timeout + time.Minute)}},
+	).Decode(&delivery)
+	if err == mongo.ErrNoDocuments {
+		return delivery, nil
+	}
+	if err != nil {
+		return delivery, err
+	}
+
+	var hook models.Webhook
+	if err := webhookCol.FindOne(ctx, bson.M{"_id": delivery.WebhookID}).Decode(&hook); err != nil || !hook.Active {
+		delivery.Status = models.WebhookDeliveryFailed
+		delivery.LastError = "webhook deleted or disabled"
+		_, err := webhookDeliveryCol.UpdateOne(ctx, bson.M{"_id": id}, bson.M{
+			"$set":   bson.M{"status": delivery.Status, "lastError": delivery.LastError},
+			"$unset": bson.M{"nextAttemptAt": ""},
+		})
+		return delivery, err
+	}
+
+	delivery.Attempts++
+	statusCode, sendErr := sendWebhook(ctx, hook, delivery)
+	set := bson.M{"attempts": delivery.Attempts, "lastStatusCode": statusCode}
+	unset := bson.M{}
+	if sendErr == nil {
+		delivered := time.Now()
+		delivery.Status = models.WebhookDeliveryDelivered
+		delivery.DeliveredAt = &delivered
+		set["deliveredAt"] = delivered
+		unset["nextAttemptAt"] = ""
+		unset["lastError"] = ""
+	} else {
+		delivery.LastError = sendErr.Error()
+		set["lastError"] = delivery.LastError
+		if delivery.Attempts >= config.GetEnvInt("WEBHOOK_MAX_ATTEMPTS", 8) {
+			delivery.Status = models.WebhookDeliveryFailed
+			unset["nextAttemptAt"] = ""
+		} else {
+			delivery.NextAttemptAt = time.Now().Add(webhookRetryDelay(delivery.Attempts))
+			set["nextAttemptAt"] = delivery.NextAttemptAt
+		}
+	}
+	delivery.LastStatusCode = statusCode
+	set["status"] = delivery.Status
+	update := bson.M{"$set": set}
+	if len(unset) > 0 {
+		update["$unset"] = unset
+	}
+	if _, err := webhookDeliveryCol.UpdateOne(ctx, bson.M{"_id": id}, update); err != nil {
+		return delivery, err
+	}
+	return delivery, sendErr
+}
+
+// sendWebhook mengirim payload dengan header tanda tangan:
+// X-Sitor-Signature = "sha256=" + HMAC-SHA256(secret, timestamp + "." + body)
+func sendWebhook(ctx context.Context, hook models.Webhook, delivery models.WebhookDelivery) (int, error) {
+	body := []byte(delivery.Payload)
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, hook.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "SITOR-Webhook/1.0")
+	req.Header.Set("X-Sitor-Event", delivery.Event)
+	req.Header.Set("X-Sitor-Delivery", delivery.ID.Hex())
+	req.Header.Set("X-Sitor-Timestamp", timestamp)
+	req.Header.Set("X-Sitor-Signature", "sha256="+utils.SignHMAC(hook.Secret, append([]byte(timestamp+"."), body...)))
+	resp, err := webhookClient.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, fmt.Errorf("HTTP %d", resp.StatusCode)
+	}
+	return resp.StatusCode, nil
+}
+
+// StartWebhookWorker mengirim ulang pengiriman webhook yang gagal sesuai jadwal backoff
+func StartWebhookWorker() {
+	runEvery("WEBHOOK", config.GetEnvDuration("WEBHOOK_RETRY_INTERVAL", 30*time.Second), retryWebhookDeliveries)
+}
+
+func retryWebhookDeliveries() {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
+	opts := options.Find().SetSort(bson.M{"nextAttemptAt": 1}).SetLimit(100).SetProjection(bson.M{"_id": 1})
+	cursor, err := webhookDeliveryCol.Find(ctx, bson.M{
+		"status":        models.WebhookDeliveryPending,
+		"nextAttemptAt": bson.M{"$lte": time.Now()},
+	}, opts)
+	if err != nil {
+		log.Printf("[WEBHOOK] failed to fetch pending deliveries: %v", err)
+		return
+	}
+	var due []models.WebhookDelivery
+	if err := cursor.All(ctx, &due); err != nil {
+		log.Printf("[WEBHOOK] failed to decode pending deliveries: %v", err)
+		return
+	}
+	for _, d := range due {
+		if _, err := attemptWebhookDelivery(ctx, d.ID); err != nil {
+			log.Printf("[WEBHOOK] retry failed for delivery %s: %v", d.ID.Hex(), err)
+		}
+	}
+}
+
+// ensureWebhookIndexes membuat index antrean pengiriman, log per webhook,
+// dan TTL log pengiriman (WEBHOOK_DELIVERY_RETENTION)
+func ensureWebhookIndexes(ctx context.Context) error {
+	retention := config.GetEnvDuration("WEBHOOK_DELIVERY_RETENTION", 30*24*time.Hour)
+	_, err := webhookDeliveryCol.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "status", Value: 1}, {Key: "nextAttemptAt", Value: 1}}},
+		{Keys: bson.D{{Key: "webhookId", Value: 1}, {Key: "createdAt", Value: -1}}},
+		{Keys: bson.D{{Key: "createdAt", Value: 1}}, Options: options.Index().SetExpireAfterSeconds(int32(retention.Seconds()))},
+	})
+	return err
+}
@@ -0,0 +1,26 @@
+package controllers
+
+import (
+	"log"
+	"time"
+)
+
+// runEvery menjalankan fn secara berkala di goroutine terpisah.
+// Panic di dalam fn dicatat dan tidak menghentikan worker.
+func runEvery(name string, interval time.Duration, fn func()) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			func() {
+				defer func() {
+					if r := recover(); r != nil {
+						log.Printf("[%s] panic: %v", name, r)
+					}
+				}()
+				fn()
+			}()
+			<-ticker.C
+		}
+	}()
+}
@@ -21,14 +21,23 @@ func main() {
 
 	// Tambahkan middleware CORS
 	app.Use(cors.New(cors.Config{
-		AllowOrigins: "*",
-		AllowHeaders: "Origin, Content-Type, Accept, Authorization",
-		AllowMethods: "GET,POST,PUT,DELETE,OPTIONS",
+		AllowOrigins:  "*",
+		AllowHeaders:  "Origin, Content-Type, Accept, Authorization, Idempotency-Key",
+		ExposeHeaders: "Idempotent-Replayed",
+		AllowMethods:  "GET,POST,PUT,DELETE,OPTIONS",
 	}))
 
 	// Inisialisasi koneksi DB sekali saja
 	db := config.GetDB()
 	controllers.InitChatHistoryCollection(db)
+	controllers.EnsureIndexes()
+
+	// Worker latar belakang
+	controllers.StartGroupPurgeWorker()
+	controllers.StartSessionScheduler()
+	controllers.StartSessionWatchdog()
+	controllers.StartArchiveWorker()
+	controllers.StartWebhookWorker()
 
 	routes.SetupRoutes(app)
 
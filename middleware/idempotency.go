@@ -0,0 +1,148 @@
+package middleware
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"time"
+
+	"sitor-backend/config"
+
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var idempotencyCol = config.GetDB().Collection("idempotency_keys")
+
+// Status kunci idempotensi
+const (
+	idempotencyProcessing = "processing"
+	idempotencyCompleted  = "completed"
+)
+
+// idempotencyRecord menyimpan hasil request pertama untuk satu kunci
+type idempotencyRecord struct {
+	ID          string    `bson:"_id"`
+	Status      string    `bson:"status"`
+	Fingerprint string    `bson:"fingerprint"`
+	StatusCode  int       `bson:"statusCode,omitempty"`
+	Body        []byte    `bson:"body,omitempty"`
+	CreatedAt   time.Time `bson:"createdAt"`
+}
+
+// idempotencyWindow adalah lama hasil request disimpan untuk replay (IDEMPOTENCY_WINDOW)
+func idempotencyWindow() time.Duration {
+	return config.GetEnvDuration("IDEMPOTENCY_WINDOW", 24*time.Hour)
+}
+
+// idempotencyKey mengambil kunci dari header Idempotency-Key, atau dari field sampleId di body JSON
+func idempotencyKey(c *fiber.Ctx) string {
+	if key := c.Get("Idempotency-Key"); key != "" {
+		return "key:" + key
+	}
+	var body struct {
+		SampleID string `json:"sampleId"`
+	}
+	if json.Unmarshal(c.Body(), &body) == nil && body.SampleID != "" {
+		return "sample:" + body.SampleID
+	}
+	return ""
+}
+
+func sha256Hex(b []byte) string {
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:])
+}
+
+// Idempotent membuat request tulis aman dikirim ulang oleh client. Request dengan kunci yang sama
+// (per user dan path) dalam IDEMPOTENCY_WINDOW tidak diproses lagi; respons pertama dikembalikan
+// dengan header Idempotent-Replayed: true. Hanya respons 2xx dan error validasi permanen (400, 422)
+// yang disimpan; respons lain (mis. 401/403, sesi dijeda, rate limit, 5xx) bisa berubah sehingga
+// client boleh mencoba ulang dengan kunci yang sama.
+// Harus dipasang setelah JWTProtected.
+func Idempotent() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		key := idempotencyKey(c)
+		if key == "" {
+			return c.Next()
+		}
+		if len(key) > 300 {
+			return c.Status(400).JSON(fiber.Map{"success": false, "message": "Idempotency key too long"})
+		}
+		userId, _ := c.Locals("userId").(string)
+		id := sha256Hex([]byte(userId + "|" + c.Method() + " " + c.Path() + "|" + key))
+		fingerprint := sha256Hex(c.Body())
+
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		record := idempotencyRecord{ID: id, Status: idempotencyProcessing, Fingerprint: fingerprint, CreatedAt: time.Now()}
+		for attempt := 0; ; attempt++ {
+			_, err := idempotencyCol.InsertOne(ctx, record)
+			if err == nil {
+				break
+			}
+			if !mongo.IsDuplicateKeyError(err) {
+				return c.Status(500).JSON(fiber.Map{"success": false, "message": "Failed to check idempotency key"})
+			}
+			var existing idempotencyRecord
+			if err := idempotencyCol.FindOne(ctx, bson.M{"_id": id}).Decode(&existing); err != nil {
+				if err == mongo.ErrNoDocuments && attempt == 0 {
+					continue
+				}
+				return c.Status(500).JSON(fiber.Map{"success": false, "message": "Failed to check idempotency key"})
+			}
+			// Kunci kedaluwarsa (TTL Mongo belum sempat menghapus) atau request pertama macet: mulai ulang
+			stale := time.Since(existing.CreatedAt) > idempotencyWindow() ||
+				(existing.Status == idempotencyProcessing && time.Since(existing.CreatedAt) > time.Minute)
+			if stale && attempt == 0 {
+				idempotencyCol.DeleteOne(ctx, bson.M{"_id": id, "createdAt": existing.CreatedAt})
+				continue
+			}
+			if existing.Fingerprint != fingerprint {
+				return c.Status(422).JSON(fiber.Map{"success": false, "message": "Idempotency key was already used with a different request"})
+			}
+			if existing.Status != idempotencyCompleted {
+				return c.Status(409).JSON(fiber.Map{"success": false, "message": "A request with this idempotency key is still in progress"})
+			}
+			c.Set("Idempotent-Replayed", "true")
+			c.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+			return c.Status(existing.StatusCode).Send(existing.Body)
+		}
+
+		err := c.Next()
+		status := c.Response().StatusCode()
+		if err != nil || !idempotencyStorable(status) {
+			idempotencyCol.DeleteOne(context.Background(), bson.M{"_id": id})
+			return err
+		}
+		_, uerr := idempotencyCol.UpdateOne(context.Background(), bson.M{"_id": id}, bson.M{"$set": bson.M{
+			"status":     idempotencyCompleted,
+			"statusCode": status,
+			"body":       c.Response().Body(),
+		}})
+		if uerr != nil {
+			idempotencyCol.DeleteOne(context.Background(), bson.M{"_id": id})
+		}
+		return nil
+	}
+}
+
+// idempotencyStorable menentukan respons yang boleh diputar ulang untuk kunci yang sama
+func idempotencyStorable(status int) bool {
+	if status >= 200 && status < 300 {
+		return true
+	}
+	return status == fiber.StatusBadRequest || status == fiber.StatusUnprocessableEntity
+}
+
+// EnsureIdempotencyIndexes membuat TTL index agar kunci idempotensi terhapus setelah IDEMPOTENCY_WINDOW
+func EnsureIdempotencyIndexes(ctx context.Context) error {
+	_, err := idempotencyCol.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "createdAt", Value: 1}},
+		Options: options.Index().SetExpireAfterSeconds(int32(idempotencyWindow().Seconds())),
+	})
+	return err
+}
@@ -0,0 +1,65 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Metrik aturan alert
+const (
+	// AlertMetricMemberEmotion: porsi sampel seorang anggota dengan emosi dominan Label >= Threshold
+	AlertMetricMemberEmotion = "member_emotion"
+	// AlertMetricMemberEngagement: rata-rata engagement seorang anggota < Threshold
+	AlertMetricMemberEngagement = "member_engagement"
+	// AlertMetricClassEngagement: rata-rata engagement kelas < Threshold
+	AlertMetricClassEngagement = "class_engagement"
+	// AlertMetricClassEngagementDrop: engagement kelas turun >= Threshold dibanding jendela sebelumnya
+	AlertMetricClassEngagementDrop = "class_engagement_drop"
+)
+
+// Status alert
+const (
+	AlertStatusOpen         = "open"
+	AlertStatusAcknowledged = "acknowledged"
+	AlertStatusResolved     = "resolved"
+)
+
+// AlertRule adalah aturan alert per grup yang dievaluasi setiap ada deteksi masuk
+type AlertRule struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	GroupID   primitive.ObjectID `bson:"groupId" json:"groupId"`
+	Name      string             `bson:"name" json:"name"`
+	Metric    string             `bson:"metric" json:"metric"`
+	Label     string             `bson:"label,omitempty" json:"label,omitempty"`
+	Threshold float64            `bson:"threshold" json:"threshold"`
+	// WindowSeconds adalah jendela waktu sampel yang dinilai
+	WindowSeconds int `bson:"windowSeconds" json:"windowSeconds"`
+	// CooldownSeconds adalah jeda minimal sebelum aturan yang sama memicu alert lagi untuk target yang sama
+	CooldownSeconds int `bson:"cooldownSeconds" json:"cooldownSeconds"`
+	// MinSamples adalah jumlah sampel minimal di jendela agar aturan dinilai
+	MinSamples int                `bson:"minSamples" json:"minSamples"`
+	Enabled    bool               `bson:"enabled" json:"enabled"`
+	CreatedBy  primitive.ObjectID `bson:"createdBy" json:"createdBy"`
+	CreatedAt  time.Time          `bson:"createdAt" json:"createdAt"`
+}
+
+// Alert adalah hasil pemicu aturan, ditangani leader dengan acknowledge lalu resolve
+type Alert struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	GroupID   primitive.ObjectID `bson:"groupId" json:"groupId"`
+	SessionID primitive.ObjectID `bson:"sessionId,omitempty" json:"sessionId"`
+	RuleID    primitive.ObjectID `bson:"ruleId" json:"ruleId"`
+	Metric    string             `bson:"metric" json:"metric"`
+	// UserID kosong untuk alert tingkat kelas
+	UserID         primitive.ObjectID `bson:"userId,omitempty" json:"userId"`
+	Value          float64            `bson:"value" json:"value"`
+	Threshold      float64            `bson:"threshold" json:"threshold"`
+	Message        string             `bson:"message" json:"message"`
+	Status         string             `bson:"status" json:"status"`
+	TriggeredAt    time.Time          `bson:"triggeredAt" json:"triggeredAt"`
+	AcknowledgedAt *time.Time         `bson:"acknowledgedAt,omitempty" json:"acknowledgedAt,omitempty"`
+	AcknowledgedBy primitive.ObjectID `bson:"acknowledgedBy,omitempty" json:"acknowledgedBy"`
+	ResolvedAt     *time.Time         `bson:"resolvedAt,omitempty" json:"resolvedAt,omitempty"`
+	ResolvedBy     primitive.ObjectID `bson:"resolvedBy,omitempty" json:"resolvedBy"`
+}
@@ -0,0 +1,26 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Jenis event kehadiran
+const (
+	AttendanceJoin      = "join"
+	AttendanceLeave     = "leave"
+	AttendanceCameraOn  = "camera_on"
+	AttendanceCameraOff = "camera_off"
+)
+
+// AttendanceEvent mencatat saat anggota masuk/keluar sesi atau menyalakan/mematikan kamera.
+// Laporan kehadiran dihitung dari urutan event ini.
+type AttendanceEvent struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	SessionID primitive.ObjectID `bson:"sessionId" json:"sessionId"`
+	GroupID   primitive.ObjectID `bson:"groupId" json:"groupId"`
+	UserID    primitive.ObjectID `bson:"userId" json:"userId"`
+	Type      string             `bson:"type" json:"type"`
+	At        time.Time          `bson:"at" json:"at"`
+}
@@ -0,0 +1,33 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// BreakoutRoom adalah sub-ruang diskusi di dalam sesi grup yang sedang aktif
+type BreakoutRoom struct {
+	ID        primitive.ObjectID   `bson:"_id,omitempty" json:"id"`
+	GroupID   primitive.ObjectID   `bson:"groupId" json:"groupId"`
+	SessionID primitive.ObjectID   `bson:"sessionId,omitempty" json:"sessionId"`
+	Name      string               `bson:"name" json:"name"`
+	Members   []primitive.ObjectID `bson:"members" json:"members"`
+	CreatedBy primitive.ObjectID   `bson:"createdBy" json:"createdBy"`
+	CreatedAt time.Time            `bson:"createdAt" json:"createdAt"`
+	// ClosedAt dan Summary diisi saat room ditutup sebelum sesi berakhir; room tetap disimpan
+	// sampai sesi diarsipkan agar ringkasannya ikut masuk arsip sesi induk
+	ClosedAt *time.Time           `bson:"closedAt,omitempty" json:"closedAt,omitempty"`
+	Summary  *BreakoutRoomSummary `bson:"summary,omitempty" json:"summary,omitempty"`
+}
+
+// BreakoutRoomSummary adalah ringkasan sub-ruang yang disimpan ke arsip sesi saat EndSession
+type BreakoutRoomSummary struct {
+	RoomID         primitive.ObjectID   `bson:"roomId" json:"roomId"`
+	Name           string               `bson:"name" json:"name"`
+	Members        []primitive.ObjectID `bson:"members" json:"members"`
+	DetectionCount int                  `bson:"detectionCount" json:"detectionCount"`
+	AverageEmotion Emotion              `bson:"averageEmotion" json:"averageEmotion"`
+	CreatedAt      time.Time            `bson:"createdAt" json:"createdAt"`
+	ClosedAt       *time.Time           `bson:"closedAt,omitempty" json:"closedAt,omitempty"`
+}
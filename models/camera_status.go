@@ -9,6 +9,7 @@ import (
 type CameraStatus struct {
 	ID        primitive.ObjectID `bson:"_id,omitempty" json:"id"`
 	GroupID   primitive.ObjectID `bson:"groupId" json:"groupId"`
+	SessionID primitive.ObjectID `bson:"sessionId,omitempty" json:"sessionId"`
 	UserID    primitive.ObjectID `bson:"userId" json:"userId"`
 	IsActive  bool               `bson:"isActive" json:"isActive"`
 	UpdatedAt time.Time          `bson:"updatedAt" json:"updatedAt"`
@@ -0,0 +1,27 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Cakupan persetujuan yang dikenal
+const (
+	ConsentScopeEmotionDetection = "emotion_detection"
+	// Sinyal perhatian (arah kepala/pandangan, mata tertutup) untuk skor engagement
+	ConsentScopeEngagement = "engagement_signals"
+)
+
+// Consent adalah persetujuan anggota untuk dianalisis wajahnya dalam satu sesi.
+// Persetujuan bisa ditarik di tengah sesi (WithdrawnAt terisi).
+type Consent struct {
+	ID            primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	UserID        primitive.ObjectID `bson:"userId" json:"userId"`
+	GroupID       primitive.ObjectID `bson:"groupId" json:"groupId"`
+	SessionID     primitive.ObjectID `bson:"sessionId" json:"sessionId"`
+	PolicyVersion string             `bson:"policyVersion" json:"policyVersion"`
+	Scope         []string           `bson:"scope" json:"scope"`
+	GrantedAt     time.Time          `bson:"grantedAt" json:"grantedAt"`
+	WithdrawnAt   *time.Time         `bson:"withdrawnAt,omitempty" json:"withdrawnAt,omitempty"`
+}
@@ -6,21 +6,20 @@ import (
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
-type Emotion struct {
-	Neutral   float64 `bson:"neutral" json:"neutral"`
-	Happy     float64 `bson:"happy" json:"happy"`
-	Sad       float64 `bson:"sad" json:"sad"`
-	Angry     float64 `bson:"angry" json:"angry"`
-	Surprised float64 `bson:"surprised" json:"surprised"`
-	Disgusted float64 `bson:"disgusted" json:"disgusted"`
-}
+// Emotion berisi nilai per label emosi (mis. "happy": 0.8, "valence": 0.3).
+// Label yang valid ditentukan oleh taksonomi aktif (package taxonomy).
+type Emotion map[string]float64
 
 type Detection struct {
 	ID        primitive.ObjectID `bson:"_id,omitempty" json:"id"`
 	GroupID   primitive.ObjectID `bson:"groupId" json:"groupId"`
+	SessionID primitive.ObjectID `bson:"sessionId,omitempty" json:"sessionId"`
 	UserID    primitive.ObjectID `bson:"userId" json:"userId"`
 	UserName  string             `bson:"userName" json:"userName"`
 	Timestamp time.Time          `bson:"timestamp" json:"timestamp"`
 	Date      string             `bson:"date" json:"date"`
 	Emotions  Emotion            `bson:"emotions" json:"emotions"`
+	// Signals dan Engagement (0..1) hanya ada jika client mengirim sinyal perhatian
+	Signals    *DetectionSignals `bson:"signals,omitempty" json:"signals,omitempty"`
+	Engagement *float64          `bson:"engagement,omitempty" json:"engagement,omitempty"`
 }
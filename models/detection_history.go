@@ -13,4 +13,6 @@ type DetectionHistory struct {
 	Detections []Detection        `bson:"detections" json:"detections"`
 	StartedAt  time.Time          `bson:"startedAt" json:"startedAt"`
 	EndedAt    time.Time          `bson:"endedAt" json:"endedAt"`
+	// Ringkasan breakout room yang digabung kembali ke arsip sesi induk
+	Rooms []BreakoutRoomSummary `bson:"rooms,omitempty" json:"rooms,omitempty"`
 }
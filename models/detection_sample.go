@@ -0,0 +1,30 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// DetectionSampleMeta adalah metaField koleksi time series detection_samples
+type DetectionSampleMeta struct {
+	GroupID   primitive.ObjectID `bson:"groupId" json:"groupId"`
+	UserID    primitive.ObjectID `bson:"userId" json:"userId"`
+	SessionID primitive.ObjectID `bson:"sessionId,omitempty" json:"sessionId"`
+}
+
+// DetectionSample adalah satu sampel deteksi emosi. Berbeda dengan Detection (hanya data terakhir
+// per user per grup), setiap sampel disimpan sehingga tren dalam sesi bisa direkonstruksi.
+type DetectionSample struct {
+	Timestamp  time.Time           `bson:"timestamp" json:"timestamp"`
+	Meta       DetectionSampleMeta `bson:"meta" json:"meta"`
+	Emotions   Emotion             `bson:"emotions" json:"emotions"`
+	Signals    *DetectionSignals   `bson:"signals,omitempty" json:"signals,omitempty"`
+	Engagement *float64            `bson:"engagement,omitempty" json:"engagement,omitempty"`
+	// Seq adalah nomor urut dari client (hanya untuk sampel batch)
+	Seq int64 `bson:"seq,omitempty" json:"seq,omitempty"`
+	// SampleID adalah ID sampel buatan client untuk mencegah sampel tersimpan ganda saat request diulang
+	SampleID string `bson:"sampleId,omitempty" json:"sampleId,omitempty"`
+	// ReceivedAt adalah waktu server menerima sampel, Timestamp bisa berasal dari jam client
+	ReceivedAt time.Time `bson:"receivedAt" json:"receivedAt"`
+}
@@ -15,6 +15,15 @@ type Group struct {
 	Members       []primitive.ObjectID `bson:"members" json:"members"`
 	CreatedAt     time.Time            `bson:"createdAt" json:"createdAt"`
 	SessionActive bool                 `bson:"sessionActive" json:"sessionActive"`
+	// ActiveSessionID menunjuk dokumen sessions yang sedang berjalan
+	ActiveSessionID primitive.ObjectID `bson:"activeSessionId,omitempty" json:"activeSessionId"`
+	// LobbyOpen true jika anggota sudah boleh check-in ke ruang tunggu sebelum sesi dimulai
+	LobbyOpen bool `bson:"lobbyOpen,omitempty" json:"lobbyOpen"`
+	// OrganizationID kosong jika grup tidak berada di bawah organisasi
+	OrganizationID primitive.ObjectID `bson:"organizationId,omitempty" json:"organizationId"`
+	// DeletedAt diisi saat grup dihapus (soft delete), grup masih bisa dipulihkan
+	// sampai masa restore habis, setelah itu dibersihkan permanen oleh worker purge
+	DeletedAt *time.Time `bson:"deletedAt,omitempty" json:"deletedAt,omitempty"`
 }
 
 // Request struct khusus untuk join group
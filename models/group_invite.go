@@ -0,0 +1,22 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// GroupInvite dibuat saat import roster berisi email yang belum terdaftar.
+// Setelah mendaftar, user harus menerima undangan secara eksplisit dengan token undangan
+// dari leader; email saat mendaftar belum diverifikasi sehingga tidak cukup sebagai bukti.
+type GroupInvite struct {
+	ID         primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	GroupID    primitive.ObjectID `bson:"groupId" json:"groupId"`
+	Email      string             `bson:"email" json:"email"`
+	Name       string             `bson:"name" json:"name"`
+	InvitedBy  primitive.ObjectID `bson:"invitedBy" json:"invitedBy"`
+	Status     string             `bson:"status" json:"status"`         // "pending" atau "accepted"
+	TokenHash  string             `bson:"tokenHash,omitempty" json:"-"` // token asli hanya dikembalikan saat import roster
+	CreatedAt  time.Time          `bson:"createdAt" json:"createdAt"`
+	AcceptedAt *time.Time         `bson:"acceptedAt,omitempty" json:"acceptedAt,omitempty"`
+}
@@ -0,0 +1,20 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// LobbyEntry adalah anggota yang sedang menunggu di ruang tunggu grup sebelum sesi dimulai
+type LobbyEntry struct {
+	ID          primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	GroupID     primitive.ObjectID `bson:"groupId" json:"groupId"`
+	UserID      primitive.ObjectID `bson:"userId" json:"userId"`
+	UserName    string             `bson:"userName" json:"userName"`
+	CheckedInAt time.Time          `bson:"checkedInAt" json:"checkedInAt"`
+	// Hasil tes kamera terakhir dari client, nil jika belum pernah tes
+	CameraOK       *bool      `bson:"cameraOk,omitempty" json:"cameraOk,omitempty"`
+	CameraError    string     `bson:"cameraError,omitempty" json:"cameraError,omitempty"`
+	CameraTestedAt *time.Time `bson:"cameraTestedAt,omitempty" json:"cameraTestedAt,omitempty"`
+}
@@ -0,0 +1,19 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Notification adalah pemberitahuan untuk user, mis. sesi diakhiri otomatis
+type Notification struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	UserID    primitive.ObjectID `bson:"userId" json:"userId"`
+	GroupID   primitive.ObjectID `bson:"groupId,omitempty" json:"groupId"`
+	SessionID primitive.ObjectID `bson:"sessionId,omitempty" json:"sessionId"`
+	Type      string             `bson:"type" json:"type"`
+	Message   string             `bson:"message" json:"message"`
+	Read      bool               `bson:"read" json:"read"`
+	CreatedAt time.Time          `bson:"createdAt" json:"createdAt"`
+}
@@ -0,0 +1,19 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Organization adalah entitas di atas grup (mis. sekolah -> kelas).
+// Admin organisasi dapat mengelola semua grup milik organisasi tanpa harus menjadi anggota grup.
+type Organization struct {
+	ID          primitive.ObjectID   `bson:"_id,omitempty" json:"id"`
+	Name        string               `bson:"name" json:"name"`
+	Description string               `bson:"description" json:"description"`
+	Admins      []primitive.ObjectID `bson:"admins" json:"admins"`
+	Members     []primitive.ObjectID `bson:"members" json:"members"`
+	CreatedBy   primitive.ObjectID   `bson:"createdBy" json:"createdBy"`
+	CreatedAt   time.Time            `bson:"createdAt" json:"createdAt"`
+}
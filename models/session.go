@@ -0,0 +1,76 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	SessionStatusActive = "active"
+	SessionStatusPaused = "paused"
+	SessionStatusEnded  = "ended"
+)
+
+// Cara sesi dimulai
+const (
+	SessionTriggerManual       = "manual"
+	SessionTriggerSchedule     = "schedule"
+	SessionTriggerGroupCreated = "group_created" // hanya sesi lama; grup baru tidak lagi langsung memulai sesi
+	SessionTriggerLegacy       = "legacy"        // sesi aktif dari sebelum ada koleksi sessions
+)
+
+// Status pengarsipan deteksi sesi ke detection_history
+const (
+	ArchiveStatusPending  = "pending"
+	ArchiveStatusArchived = "archived"
+	ArchiveStatusFailed   = "failed"
+)
+
+// Alasan sesi diakhiri
+const (
+	SessionEndManual       = "manual"
+	SessionEndSchedule     = "schedule"
+	SessionEndMaxDuration  = "max_duration"
+	SessionEndIdle         = "idle"
+	SessionEndGroupDeleted = "group_deleted"
+	SessionEndReplaced     = "replaced" // sesi lama tanpa dokumen sessions digantikan sesi baru
+)
+
+// SessionPause adalah satu interval jeda (mis. istirahat) di timeline sesi
+type SessionPause struct {
+	StartedAt time.Time          `bson:"startedAt" json:"startedAt"`
+	EndedAt   *time.Time         `bson:"endedAt,omitempty" json:"endedAt,omitempty"`
+	PausedBy  primitive.ObjectID `bson:"pausedBy" json:"pausedBy"`
+}
+
+// SessionArchive mencatat proses pengarsipan sesi. Deteksi aktif baru dihapus
+// setelah arsipnya tersimpan, pengarsipan yang gagal dicoba ulang oleh worker.
+type SessionArchive struct {
+	Status        string     `bson:"status" json:"status"`
+	Attempts      int        `bson:"attempts" json:"attempts"`
+	LastError     string     `bson:"lastError,omitempty" json:"lastError,omitempty"`
+	NextAttemptAt time.Time  `bson:"nextAttemptAt" json:"nextAttemptAt"`
+	ArchivedAt    *time.Time `bson:"archivedAt,omitempty" json:"archivedAt,omitempty"`
+}
+
+// Session adalah satu sesi grup, dari StartSession sampai EndSession
+type Session struct {
+	ID           primitive.ObjectID   `bson:"_id,omitempty" json:"id"`
+	GroupID      primitive.ObjectID   `bson:"groupId" json:"groupId"`
+	StartedBy    primitive.ObjectID   `bson:"startedBy,omitempty" json:"startedBy"` // kosong jika dimulai scheduler
+	Trigger      string               `bson:"trigger" json:"trigger"`
+	StartedAt    time.Time            `bson:"startedAt" json:"startedAt"`
+	EndedAt      *time.Time           `bson:"endedAt,omitempty" json:"endedAt,omitempty"`
+	Status       string               `bson:"status" json:"status"`
+	Participants []primitive.ObjectID `bson:"participants" json:"participants"`
+	// LastActivityAt diperbarui saat ada kamera aktif atau deteksi masuk, dipakai untuk idle timeout
+	LastActivityAt time.Time `bson:"lastActivityAt,omitempty" json:"lastActivityAt,omitempty"`
+	EndReason      string    `bson:"endReason,omitempty" json:"endReason,omitempty"`
+	// Pauses mencatat interval jeda, sesi yang dijeda tetap diarsipkan sebagai satu DetectionHistory
+	Pauses  []SessionPause  `bson:"pauses,omitempty" json:"pauses"`
+	Archive *SessionArchive `bson:"archive,omitempty" json:"archive,omitempty"`
+	// JoinPIN adalah PIN 6 digit untuk bergabung ke sesi, hanya ada selama sesi berjalan.
+	// Tidak ikut di JSON umum, hanya diberikan ke leader lewat endpoint khusus.
+	JoinPIN string `bson:"joinPin,omitempty" json:"-"`
+}
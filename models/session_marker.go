@@ -0,0 +1,20 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// SessionMarker adalah penanda momen di timeline sesi (mis. "quiz started", "break")
+// yang ditampilkan bersama grafik deteksi di riwayat
+type SessionMarker struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	SessionID primitive.ObjectID `bson:"sessionId" json:"sessionId"`
+	GroupID   primitive.ObjectID `bson:"groupId" json:"groupId"`
+	Label     string             `bson:"label" json:"label"`
+	Note      string             `bson:"note,omitempty" json:"note,omitempty"`
+	At        time.Time          `bson:"at" json:"at"`
+	CreatedBy primitive.ObjectID `bson:"createdBy" json:"createdBy"`
+	CreatedAt time.Time          `bson:"createdAt" json:"createdAt"`
+}
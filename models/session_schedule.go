@@ -0,0 +1,33 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ScheduleSlot adalah satu jadwal mingguan, mis. setiap Senin 08:00
+type ScheduleSlot struct {
+	Weekday   int    `bson:"weekday" json:"weekday"`     // 0 = Minggu ... 6 = Sabtu
+	StartTime string `bson:"startTime" json:"startTime"` // "HH:MM" pada zona waktu jadwal
+}
+
+// SessionSchedule adalah jadwal sesi berulang milik grup.
+// Scheduler memulai dan mengakhiri sesi otomatis sesuai slot, kecuali tanggal di Exceptions.
+type SessionSchedule struct {
+	ID              primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	GroupID         primitive.ObjectID `bson:"groupId" json:"groupId"`
+	Name            string             `bson:"name" json:"name"`
+	Timezone        string             `bson:"timezone" json:"timezone"`
+	Slots           []ScheduleSlot     `bson:"slots" json:"slots"`
+	DurationMinutes int                `bson:"durationMinutes" json:"durationMinutes"`
+	Exceptions      []string           `bson:"exceptions" json:"exceptions"` // tanggal libur "YYYY-MM-DD"
+	Active          bool               `bson:"active" json:"active"`
+	CreatedBy       primitive.ObjectID `bson:"createdBy" json:"createdBy"`
+	CreatedAt       time.Time          `bson:"createdAt" json:"createdAt"`
+	// LastStartedAt adalah waktu mulai occurrence terakhir yang sudah diproses scheduler
+	LastStartedAt time.Time `bson:"lastStartedAt,omitempty" json:"lastStartedAt,omitempty"`
+	// RunningUntil dan RunningSessionID diisi jika sesi yang sedang berjalan dimulai oleh scheduler
+	RunningUntil     *time.Time         `bson:"runningUntil,omitempty" json:"runningUntil,omitempty"`
+	RunningSessionID primitive.ObjectID `bson:"runningSessionId,omitempty" json:"runningSessionId,omitempty"`
+}
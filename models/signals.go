@@ -0,0 +1,24 @@
+package models
+
+// HeadPose adalah sudut kepala dalam derajat (0 berarti menghadap lurus ke kamera)
+type HeadPose struct {
+	Yaw   float64 `bson:"yaw" json:"yaw"`
+	Pitch float64 `bson:"pitch" json:"pitch"`
+	Roll  float64 `bson:"roll" json:"roll"`
+}
+
+// Gaze adalah arah pandangan mata dalam derajat relatif terhadap kamera
+type Gaze struct {
+	Yaw   float64 `bson:"yaw" json:"yaw"`
+	Pitch float64 `bson:"pitch" json:"pitch"`
+}
+
+// DetectionSignals adalah sinyal perhatian opsional yang dikirim bersama deteksi emosi.
+// Semua field opsional; yang tidak dikirim tidak ikut dihitung di skor engagement.
+type DetectionSignals struct {
+	FacePresent     *bool     `bson:"facePresent,omitempty" json:"facePresent,omitempty"`
+	FaceCount       *int      `bson:"faceCount,omitempty" json:"faceCount,omitempty"`
+	HeadPose        *HeadPose `bson:"headPose,omitempty" json:"headPose,omitempty"`
+	Gaze            *Gaze     `bson:"gaze,omitempty" json:"gaze,omitempty"`
+	EyesClosedRatio *float64  `bson:"eyesClosedRatio,omitempty" json:"eyesClosedRatio,omitempty"`
+}
@@ -0,0 +1,55 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Event yang bisa dilanggani webhook
+const (
+	WebhookEventSessionStarted = "session.started"
+	WebhookEventSessionEnded   = "session.ended"
+	WebhookEventMemberJoined   = "member.joined"
+	WebhookEventAlertTriggered = "alert.triggered"
+	// WebhookEventTest hanya dikirim lewat endpoint uji coba
+	WebhookEventTest = "webhook.test"
+)
+
+// Status pengiriman webhook
+const (
+	WebhookDeliveryPending   = "pending"
+	WebhookDeliveryDelivered = "delivered"
+	WebhookDeliveryFailed    = "failed"
+)
+
+// Webhook adalah langganan event milik satu grup atau satu organisasi (berlaku untuk semua grupnya).
+// Payload ditandatangani HMAC-SHA256 dengan Secret.
+type Webhook struct {
+	ID             primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	GroupID        primitive.ObjectID `bson:"groupId,omitempty" json:"groupId,omitempty"`
+	OrganizationID primitive.ObjectID `bson:"organizationId,omitempty" json:"organizationId,omitempty"`
+	URL            string             `bson:"url" json:"url"`
+	Secret         string             `bson:"secret" json:"-"`
+	Events         []string           `bson:"events" json:"events"`
+	Active         bool               `bson:"active" json:"active"`
+	CreatedBy      primitive.ObjectID `bson:"createdBy" json:"createdBy"`
+	CreatedAt      time.Time          `bson:"createdAt" json:"createdAt"`
+}
+
+// WebhookDelivery adalah satu pengiriman event ke webhook sekaligus log-nya.
+// Payload disimpan apa adanya agar percobaan ulang mengirim body yang sama persis.
+type WebhookDelivery struct {
+	ID             primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	WebhookID      primitive.ObjectID `bson:"webhookId" json:"webhookId"`
+	GroupID        primitive.ObjectID `bson:"groupId,omitempty" json:"groupId,omitempty"`
+	Event          string             `bson:"event" json:"event"`
+	Payload        string             `bson:"payload" json:"payload"`
+	Status         string             `bson:"status" json:"status"`
+	Attempts       int                `bson:"attempts" json:"attempts"`
+	NextAttemptAt  time.Time          `bson:"nextAttemptAt,omitempty" json:"nextAttemptAt,omitempty"`
+	LastStatusCode int                `bson:"lastStatusCode,omitempty" json:"lastStatusCode,omitempty"`
+	LastError      string             `bson:"lastError,omitempty" json:"lastError,omitempty"`
+	CreatedAt      time.Time          `bson:"createdAt" json:"createdAt"`
+	DeliveredAt    *time.Time         `bson:"deliveredAt,omitempty" json:"deliveredAt,omitempty"`
+}
@@ -1,6 +1,8 @@
 package routes
 
 import (
+	"time"
+
 	"sitor-backend/controllers"
 	"sitor-backend/middleware"
 
@@ -16,6 +18,8 @@ func SetupRoutes(app *fiber.App) {
 	api.Get("/me/summary", middleware.JWTProtected(), controllers.MeSummary)
 	api.Patch("/me", middleware.JWTProtected(), controllers.UpdateProfile)
 	api.Patch("/me/password", middleware.JWTProtected(), controllers.UpdatePassword)
+	api.Get("/me/notifications", middleware.JWTProtected(), controllers.GetNotifications)
+	api.Post("/me/notifications/:id/read", middleware.JWTProtected(), controllers.MarkNotificationRead)
 
 	// Group
 	api.Get("/groups", controllers.GetGroups)
@@ -23,20 +27,119 @@ func SetupRoutes(app *fiber.App) {
 	api.Post("/groups/join", middleware.JWTProtected(), controllers.JoinGroup)
 	api.Get("/groups/:id/members", middleware.JWTProtected(), controllers.ListGroupMembers)
 	api.Delete("/groups/:id", middleware.JWTProtected(), controllers.DeleteGroup)
+	api.Post("/groups/:id/restore", middleware.JWTProtected(), controllers.RestoreGroup)
+	api.Get("/groups/:id/export", middleware.JWTProtected(), controllers.ExportGroup)
 	api.Post("/groups/:id/leave", middleware.JWTProtected(), controllers.LeaveGroup)
+	api.Post("/groups/:id/roster/import", middleware.JWTProtected(), controllers.ImportGroupRoster)
+	api.Get("/groups/:id/roster/export", middleware.JWTProtected(), controllers.ExportGroupRoster)
+	api.Post("/invites/accept", middleware.JWTProtected(), controllers.AcceptGroupInvite)
 	// End session (disconnect all users in group, but keep group)
 	api.Post("/groups/:groupId/end-session", middleware.JWTProtected(), controllers.EndSession)
 	// Start session (activate sessionActive on group)
 	api.Post("/groups/:groupId/start-session", middleware.JWTProtected(), controllers.StartSession)
+	// Pause / resume session (tetap satu arsip sesi)
+	api.Post("/groups/:groupId/pause-session", middleware.JWTProtected(), controllers.PauseSession)
+	api.Post("/groups/:groupId/resume-session", middleware.JWTProtected(), controllers.ResumeSession)
+
+	// Organization (sekolah -> kelas)
+	api.Post("/organizations", middleware.JWTProtected(), controllers.CreateOrganization)
+	api.Get("/organizations", middleware.JWTProtected(), controllers.ListOrganizations)
+	api.Get("/organizations/:id", middleware.JWTProtected(), controllers.GetOrganization)
+	api.Post("/organizations/:id/members", middleware.JWTProtected(), controllers.AddOrganizationMember)
+	api.Delete("/organizations/:id/members/:userId", middleware.JWTProtected(), controllers.RemoveOrganizationMember)
+	api.Put("/organizations/:id/admins/:userId", middleware.JWTProtected(), controllers.SetOrganizationAdmin)
+	api.Get("/organizations/:id/roster", middleware.JWTProtected(), controllers.GetOrganizationRoster)
+	api.Get("/organizations/:id/groups", middleware.JWTProtected(), controllers.ListOrganizationGroups)
+	api.Post("/organizations/:id/groups", middleware.JWTProtected(), controllers.AttachOrganizationGroup)
+	api.Delete("/organizations/:id/groups/:groupId", middleware.JWTProtected(), controllers.DetachOrganizationGroup)
+	api.Get("/organizations/:id/analytics", middleware.JWTProtected(), controllers.GetOrganizationAnalytics)
+	api.Post("/organizations/:id/webhooks", middleware.JWTProtected(), controllers.CreateOrganizationWebhook)
+	api.Get("/organizations/:id/webhooks", middleware.JWTProtected(), controllers.ListOrganizationWebhooks)
+
+	// Sesi
+	api.Get("/groups/:groupId/sessions", middleware.JWTProtected(), controllers.GetGroupSessions)
+	api.Get("/sessions/:id", middleware.JWTProtected(), controllers.GetSession)
+	api.Get("/sessions/:id/archive", middleware.JWTProtected(), controllers.GetSessionArchiveStatus)
+	api.Post("/sessions/:id/archive/retry", middleware.JWTProtected(), controllers.RetrySessionArchive)
+	api.Post("/groups/:groupId/session/join", middleware.JWTProtected(), controllers.JoinSession)
+	api.Post("/groups/:groupId/session/leave", middleware.JWTProtected(), controllers.LeaveSession)
+	api.Get("/groups/:groupId/session/pin", middleware.JWTProtected(), controllers.GetSessionPIN)
+	api.Post("/sessions/join-pin", middleware.JWTProtected(), middleware.PerUserRateLimit(10, time.Minute), controllers.JoinSessionByPIN)
+
+	// Marker timeline sesi
+	api.Post("/groups/:groupId/markers", middleware.JWTProtected(), controllers.CreateSessionMarker)
+	api.Delete("/groups/:groupId/markers/:markerId", middleware.JWTProtected(), controllers.DeleteSessionMarker)
+	api.Get("/sessions/:id/markers", middleware.JWTProtected(), controllers.GetSessionMarkers)
+
+	// Ruang tunggu sebelum sesi dimulai
+	api.Post("/groups/:groupId/lobby/open", middleware.JWTProtected(), controllers.OpenLobby)
+	api.Post("/groups/:groupId/lobby/close", middleware.JWTProtected(), controllers.CloseLobby)
+	api.Get("/groups/:groupId/lobby", middleware.JWTProtected(), controllers.GetLobby)
+	api.Post("/groups/:groupId/lobby/check-in", middleware.JWTProtected(), controllers.CheckInLobby)
+	api.Delete("/groups/:groupId/lobby/check-in", middleware.JWTProtected(), controllers.LeaveLobby)
+	api.Post("/groups/:groupId/lobby/camera-test", middleware.JWTProtected(), controllers.ReportLobbyCameraTest)
+
+	// Persetujuan analisis wajah per sesi
+	api.Get("/consent/policy", middleware.JWTProtected(), controllers.GetConsentPolicy)
+	api.Post("/groups/:groupId/consent", middleware.JWTProtected(), controllers.GrantConsent)
+	api.Delete("/groups/:groupId/consent", middleware.JWTProtected(), controllers.WithdrawConsent)
+	api.Get("/groups/:groupId/consent/me", middleware.JWTProtected(), controllers.GetMyConsent)
+	api.Get("/groups/:groupId/consent/status", middleware.JWTProtected(), controllers.GetConsentStatus)
+
+	// Kehadiran
+	api.Get("/sessions/:id/attendance", middleware.JWTProtected(), controllers.GetSessionAttendance)
+	api.Get("/groups/:groupId/attendance", middleware.JWTProtected(), controllers.GetGroupAttendance)
+
+	// Jadwal sesi berulang
+	api.Get("/groups/:groupId/schedules/upcoming", middleware.JWTProtected(), controllers.GetUpcomingSessions)
+	api.Post("/groups/:groupId/schedules", middleware.JWTProtected(), controllers.CreateSchedule)
+	api.Get("/groups/:groupId/schedules", middleware.JWTProtected(), controllers.ListSchedules)
+	api.Put("/groups/:groupId/schedules/:scheduleId", middleware.JWTProtected(), controllers.UpdateSchedule)
+	api.Delete("/groups/:groupId/schedules/:scheduleId", middleware.JWTProtected(), controllers.DeleteSchedule)
+	api.Post("/groups/:groupId/schedules/:scheduleId/exceptions", middleware.JWTProtected(), controllers.AddScheduleException)
+	api.Delete("/groups/:groupId/schedules/:scheduleId/exceptions/:date", middleware.JWTProtected(), controllers.RemoveScheduleException)
+
+	// Breakout room (sub-ruang di dalam sesi aktif)
+	api.Post("/groups/:groupId/rooms", middleware.JWTProtected(), controllers.CreateBreakoutRoom)
+	api.Get("/groups/:groupId/rooms", middleware.JWTProtected(), controllers.ListBreakoutRooms)
+	api.Put("/groups/:groupId/rooms/:roomId/members", middleware.JWTProtected(), controllers.AssignBreakoutRoomMembers)
+	api.Delete("/groups/:groupId/rooms/:roomId", middleware.JWTProtected(), controllers.CloseBreakoutRoom)
+	api.Get("/groups/:groupId/rooms/:roomId/camera-status", middleware.JWTProtected(), controllers.GetBreakoutRoomCameraStatus)
+	api.Get("/groups/:groupId/rooms/:roomId/detections", middleware.JWTProtected(), controllers.GetBreakoutRoomDetections)
 
 	// Detection
-	api.Post("/detections", middleware.JWTProtected(), controllers.CreateDetection)
+	api.Get("/taxonomy", middleware.JWTProtected(), controllers.GetEmotionTaxonomy)
+	api.Post("/detections", middleware.JWTProtected(), middleware.Idempotent(), controllers.CreateDetection)
+	api.Post("/detections/batch", middleware.JWTProtected(), middleware.Idempotent(), controllers.CreateDetectionBatch)
 	api.Get("/detections/:groupId", middleware.JWTProtected(), controllers.GetDetectionsByGroup)
+	api.Get("/sessions/:id/samples", middleware.JWTProtected(), controllers.GetSessionSamples)
+	api.Get("/groups/:groupId/engagement", middleware.JWTProtected(), controllers.GetGroupEngagement)
+	api.Get("/sessions/:id/engagement", middleware.JWTProtected(), controllers.GetSessionEngagement)
 	// Detection history (riwayat sesi)
 	api.Get("/groups/:groupId/history", middleware.JWTProtected(), controllers.GetDetectionHistory)
+	api.Get("/groups/:groupId/dashboard", middleware.JWTProtected(), controllers.GetGroupDashboard)
+	api.Get("/groups/:groupId/mood", middleware.JWTProtected(), controllers.GetGroupMood)
+
+	// Alert kesejahteraan
+	api.Post("/groups/:groupId/alert-rules", middleware.JWTProtected(), controllers.CreateAlertRule)
+	api.Get("/groups/:groupId/alert-rules", middleware.JWTProtected(), controllers.ListAlertRules)
+	api.Put("/groups/:groupId/alert-rules/:ruleId", middleware.JWTProtected(), controllers.UpdateAlertRule)
+	api.Delete("/groups/:groupId/alert-rules/:ruleId", middleware.JWTProtected(), controllers.DeleteAlertRule)
+	api.Get("/groups/:groupId/alerts", middleware.JWTProtected(), controllers.ListAlerts)
+	api.Post("/groups/:groupId/alerts/:alertId/acknowledge", middleware.JWTProtected(), controllers.AcknowledgeAlert)
+	api.Post("/groups/:groupId/alerts/:alertId/resolve", middleware.JWTProtected(), controllers.ResolveAlert)
+
+	// Webhook keluar
+	api.Post("/groups/:groupId/webhooks", middleware.JWTProtected(), controllers.CreateGroupWebhook)
+	api.Get("/groups/:groupId/webhooks", middleware.JWTProtected(), controllers.ListGroupWebhooks)
+	api.Put("/webhooks/:webhookId", middleware.JWTProtected(), controllers.UpdateWebhook)
+	api.Delete("/webhooks/:webhookId", middleware.JWTProtected(), controllers.DeleteWebhook)
+	api.Post("/webhooks/:webhookId/rotate-secret", middleware.JWTProtected(), controllers.RotateWebhookSecret)
+	api.Get("/webhooks/:webhookId/deliveries", middleware.JWTProtected(), controllers.GetWebhookDeliveries)
+	api.Post("/webhooks/:webhookId/test", middleware.JWTProtected(), controllers.TestWebhook)
 
 	// Camera status
-	api.Post("/groups/:groupId/camera-status", middleware.JWTProtected(), controllers.UpdateCameraStatus)
+	api.Post("/groups/:groupId/camera-status", middleware.JWTProtected(), middleware.Idempotent(), controllers.UpdateCameraStatus)
 	api.Get("/groups/:groupId/camera-status", middleware.JWTProtected(), controllers.GetCameraStatus)
 
 	// Chat history
@@ -0,0 +1,157 @@
+package taxonomy
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"regexp"
+	"sort"
+	"sync"
+)
+
+// Range adalah rentang nilai yang valid untuk satu dimensi (mis. valence -1..1)
+type Range struct {
+	Min float64 `json:"min"`
+	Max float64 `json:"max"`
+}
+
+// Taxonomy adalah himpunan label yang dikeluarkan satu model detektor.
+// Categories adalah label probabilitas (masing-masing 0..1, totalnya ~1),
+// Dimensions adalah nilai kontinu seperti valence/arousal dengan rentangnya sendiri.
+type Taxonomy struct {
+	Name       string           `json:"name"`
+	Categories []string         `json:"categories"`
+	Dimensions map[string]Range `json:"dimensions,omitempty"`
+}
+
+// IsCategory mengecek apakah label adalah kategori emosi
+func (t Taxonomy) IsCategory(label string) bool {
+	for _, c := range t.Categories {
+		if c == label {
+			return true
+		}
+	}
+	return false
+}
+
+// Dimension mengembalikan rentang dimensi jika label adalah dimensi
+func (t Taxonomy) Dimension(label string) (Range, bool) {
+	r, ok := t.Dimensions[label]
+	return r, ok
+}
+
+// Labels mengembalikan semua label: kategori lalu dimensi
+func (t Taxonomy) Labels() []string {
+	dims := []string{}
+	for d := range t.Dimensions {
+		dims = append(dims, d)
+	}
+	sort.Strings(dims)
+	return append(append([]string{}, t.Categories...), dims...)
+}
+
+var labelPattern = regexp.MustCompile(`^[a-z][a-z0-9_]*$`)
+
+var (
+	mu       sync.RWMutex
+	registry = map[string]Taxonomy{}
+	current  *Taxonomy
+)
+
+func init() {
+	Register(Taxonomy{Name: "basic6", Categories: []string{"neutral", "happy", "sad", "angry", "surprised", "disgusted"}})
+	Register(Taxonomy{Name: "fer7", Categories: []string{"neutral", "happy", "sad", "angry", "surprised", "disgusted", "fear"}})
+	Register(Taxonomy{Name: "fer8", Categories: []string{"neutral", "happy", "sad", "angry", "surprised", "disgusted", "fear", "contempt"}})
+	Register(Taxonomy{
+		Name:       "fer8-va",
+		Categories: []string{"neutral", "happy", "sad", "angry", "surprised", "disgusted", "fear", "contempt"},
+		Dimensions: map[string]Range{"valence": {Min: -1, Max: 1}, "arousal": {Min: -1, Max: 1}},
+	})
+}
+
+// Register menambahkan atau mengganti taksonomi di registry
+func Register(t Taxonomy) {
+	mu.Lock()
+	defer mu.Unlock()
+	registry[t.Name] = t
+}
+
+// Get mengambil taksonomi dari registry berdasarkan nama
+func Get(name string) (Taxonomy, bool) {
+	mu.RLock()
+	defer mu.RUnlock()
+	t, ok := registry[name]
+	return t, ok
+}
+
+// Current mengembalikan taksonomi yang aktif. Dipilih sekali dari environment:
+// EMOTION_TAXONOMY_FILE (file JSON taksonomi kustom) atau EMOTION_TAXONOMY (nama di registry, default basic6).
+func Current() Taxonomy {
+	mu.RLock()
+	if current != nil {
+		defer mu.RUnlock()
+		return *current
+	}
+	mu.RUnlock()
+	t := load()
+	mu.Lock()
+	defer mu.Unlock()
+	if current == nil {
+		current = &t
+	}
+	return *current
+}
+
+func load() Taxonomy {
+	if path := os.Getenv("EMOTION_TAXONOMY_FILE"); path != "" {
+		t, err := loadFile(path)
+		if err == nil {
+			Register(t)
+			return t
+		}
+		log.Printf("[TAXONOMY] failed to load %s, using default: %v", path, err)
+	}
+	name := os.Getenv("EMOTION_TAXONOMY")
+	if name == "" {
+		name = "basic6"
+	}
+	t, ok := Get(name)
+	if !ok {
+		log.Printf("[TAXONOMY] unknown taxonomy %q, using basic6", name)
+		t, _ = Get("basic6")
+	}
+	return t
+}
+
+func loadFile(path string) (Taxonomy, error) {
+	var t Taxonomy
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return t, err
+	}
+	if err := json.Unmarshal(data, &t); err != nil {
+		return t, err
+	}
+	if t.Name == "" {
+		return t, fmt.Errorf("taxonomy name is required")
+	}
+	if len(t.Categories) == 0 && len(t.Dimensions) == 0 {
+		return t, fmt.Errorf("taxonomy %s has no labels", t.Name)
+	}
+	// Label dipakai sebagai nama field MongoDB, jadi dibatasi huruf kecil, angka, dan garis bawah
+	for _, label := range t.Labels() {
+		if !labelPattern.MatchString(label) {
+			return t, fmt.Errorf("invalid label %q", label)
+		}
+	}
+	for name, r := range t.Dimensions {
+		if r.Min >= r.Max {
+			return t, fmt.Errorf("dimension %s has an invalid range", name)
+		}
+		if t.IsCategory(name) {
+			return t, fmt.Errorf("label %s is both a category and a dimension", name)
+		}
+	}
+	return t, nil
+}
@@ -0,0 +1,20 @@
+package utils
+
+import (
+	"crypto/rand"
+	"math/big"
+	"strings"
+)
+
+// GeneratePIN membuat PIN numerik acak sepanjang digits (boleh diawali nol)
+func GeneratePIN(digits int) (string, error) {
+	var sb strings.Builder
+	for i := 0; i < digits; i++ {
+		n, err := rand.Int(rand.Reader, big.NewInt(10))
+		if err != nil {
+			return "", err
+		}
+		sb.WriteByte(byte('0' + n.Int64()))
+	}
+	return sb.String(), nil
+}